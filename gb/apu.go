@@ -0,0 +1,203 @@
+package gb
+
+import "errors"
+
+type apu interface {
+	// tick moves the APU forward by a single quartz-cycle, returning the
+	// stereo sample it's outputting, with each channel between -1 and 1.
+	tick() (left, right float32)
+
+	// snapshot returns the APU's internal state for save states, and restore
+	// loads it back.
+	snapshot() gbAPUState
+	restore(gbAPUState)
+}
+
+const (
+	gbAddrNR10 uint32 = 0xFF10 // first sound register
+	gbAddrNR11 uint32 = 0xFF11 // channel 1 duty and length
+	gbAddrNR14 uint32 = 0xFF14 // channel 1 trigger and length enable
+	gbAddrNR21 uint32 = 0xFF16 // channel 2 duty and length
+	gbAddrNR24 uint32 = 0xFF19 // channel 2 trigger and length enable
+	gbAddrNR31 uint32 = 0xFF1B // channel 3 length
+	gbAddrNR34 uint32 = 0xFF1E // channel 3 trigger and length enable
+	gbAddrNR41 uint32 = 0xFF20 // channel 4 length
+	gbAddrNR44 uint32 = 0xFF23 // channel 4 trigger and length enable
+	gbAddrNR52 uint32 = 0xFF26 // sound on/off and channel status
+
+	gbNRx4Trigger      uint8 = 0x1 << 7
+	gbNRx4LengthEnable uint8 = 0x1 << 6
+	gbNR52Power        uint8 = 0x1 << 7
+	gbNR52UnusedBits   uint8 = 0x70 // 0b01110000
+
+	gbSoundChannels = 4
+	gbLengthMax     = 64  // for the pulse and noise channels
+	gbWaveLengthMax = 256 // for the wave channel
+)
+
+var (
+	gbErrAPUAddress = errors.New("gbAPU: address isn't a sound register")
+)
+
+// gbSoundChannel is the state shared by all four sound channels. A channel's
+// length counter counts down at 256Hz while it's enabled in NRx4, turning the
+// channel off when it reaches zero.
+type gbSoundChannel struct {
+	nrx1, nrx4 uint32 // addresses of the length and trigger registers
+	maxLength  int
+
+	enabled       bool
+	length        int
+	lengthEnabled bool
+}
+
+// trigger restarts the channel, reloading the length counter if it has run
+// out.
+func (c *gbSoundChannel) trigger() {
+	c.enabled = true
+	if c.length == 0 {
+		c.length = c.maxLength
+	}
+}
+
+func (c *gbSoundChannel) clockLength() {
+	if !c.lengthEnabled || c.length == 0 {
+		return
+	}
+
+	c.length--
+	if c.length == 0 {
+		c.enabled = false
+	}
+}
+
+// gbAPU is the audio processing unit, which backs the sound registers.
+// TODO(guy): The channels don't generate any sound yet, so it's silent.
+type gbAPU struct {
+	seq      *frameSequencer
+	channels [gbSoundChannels]gbSoundChannel
+	sweep    gbSweep // channel 1 only
+
+	regs [gbAddrNR52 - gbAddrNR10 + 1]uint8
+}
+
+// gbSoundChannelState is the serialisable state of a gbSoundChannel.
+type gbSoundChannelState struct {
+	Enabled       bool
+	Length        int
+	LengthEnabled bool
+}
+
+// gbAPUState is the serialisable internal state of a gbAPU.
+type gbAPUState struct {
+	SeqStep, SeqCycles int
+	Channels           [gbSoundChannels]gbSoundChannelState
+
+	SweepEnabled bool
+	SweepTimer   int
+	SweepShadow  uint16
+
+	Regs [gbAddrNR52 - gbAddrNR10 + 1]uint8
+}
+
+func newGBAPU() *gbAPU {
+	a := &gbAPU{
+		seq: newFrameSequencer(),
+		channels: [gbSoundChannels]gbSoundChannel{
+			{nrx1: gbAddrNR11, nrx4: gbAddrNR14, maxLength: gbLengthMax},
+			{nrx1: gbAddrNR21, nrx4: gbAddrNR24, maxLength: gbLengthMax},
+			{nrx1: gbAddrNR31, nrx4: gbAddrNR34, maxLength: gbWaveLengthMax},
+			{nrx1: gbAddrNR41, nrx4: gbAddrNR44, maxLength: gbLengthMax},
+		},
+	}
+	a.seq.length = a.clockLengths
+	a.seq.sweep = a.clockSweep
+
+	return a
+}
+
+func (a *gbAPU) snapshot() gbAPUState {
+	s := gbAPUState{
+		SeqStep:      a.seq.step,
+		SeqCycles:    a.seq.cycles,
+		SweepEnabled: a.sweep.enabled,
+		SweepTimer:   a.sweep.timer,
+		SweepShadow:  a.sweep.shadow,
+		Regs:         a.regs,
+	}
+	for i, c := range a.channels {
+		s.Channels[i] = gbSoundChannelState{c.enabled, c.length, c.lengthEnabled}
+	}
+
+	return s
+}
+
+func (a *gbAPU) restore(s gbAPUState) {
+	a.seq.step, a.seq.cycles = s.SeqStep, s.SeqCycles
+	a.sweep = gbSweep{s.SweepEnabled, s.SweepTimer, s.SweepShadow}
+	a.regs = s.Regs
+	for i, c := range s.Channels {
+		ch := &a.channels[i]
+		ch.enabled, ch.length, ch.lengthEnabled = c.Enabled, c.Length, c.LengthEnabled
+	}
+}
+
+func (a *gbAPU) tick() (left, right float32) {
+	a.seq.Tick(1)
+	return 0, 0
+}
+
+func (a *gbAPU) clockLengths() {
+	for i := range a.channels {
+		a.channels[i].clockLength()
+	}
+}
+
+func (a *gbAPU) poke(addr uint32, val uint8) error {
+	if addr < gbAddrNR10 || addr > gbAddrNR52 {
+		return gbErrAPUAddress
+	}
+	a.regs[addr-gbAddrNR10] = val
+
+	for i := range a.channels {
+		c := &a.channels[i]
+		switch addr {
+		case c.nrx1:
+			c.length = c.maxLength - int(val)%c.maxLength
+
+		case c.nrx4:
+			c.lengthEnabled = val&gbNRx4LengthEnable != 0
+			if val&gbNRx4Trigger == 0 {
+				break
+			}
+			c.trigger()
+			if i == 0 {
+				a.triggerSweep()
+			}
+		}
+	}
+
+	return nil
+}
+
+func (a *gbAPU) read(addr uint32) (uint8, error) {
+	if addr < gbAddrNR10 || addr > gbAddrNR52 {
+		return 0, gbErrAPUAddress
+	}
+
+	if addr == gbAddrNR52 {
+		val := a.regs[addr-gbAddrNR10]&gbNR52Power | gbNR52UnusedBits
+		for i, c := range a.channels {
+			if c.enabled {
+				val |= 0x1 << i
+			}
+		}
+		return val, nil
+	}
+
+	return a.regs[addr-gbAddrNR10], nil
+}
+
+func (a *gbAPU) ReadSlice(addr, n uint32) ([]uint8, error) {
+	return readN(a, addr, n)
+}
@@ -0,0 +1,90 @@
+package gb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestAPULengthCounters tests that each channel is turned off once its
+// length counter runs out.
+func TestAPULengthCounters(t *testing.T) {
+	const lengthPeriod = 2 * gbFrameSequencerCycles // 256Hz
+
+	for i := 0; i < gbSoundChannels; i++ {
+		a := newGBAPU()
+		c := &a.channels[i]
+		bit := uint8(0x1) << i
+		status := func() uint8 {
+			val, err := a.read(gbAddrNR52)
+			assert.NoError(t, err)
+			return val & bit
+		}
+
+		assert.NoError(t, a.poke(c.nrx1, uint8(c.maxLength-2)))
+		assert.NoError(t, a.poke(c.nrx4, gbNRx4Trigger|gbNRx4LengthEnable))
+		assert.Equal(t, bit, status(), "channel %d", i+1)
+
+		a.seq.Tick(lengthPeriod)
+		assert.Equal(t, bit, status(), "channel %d", i+1)
+		a.seq.Tick(lengthPeriod)
+		assert.Equal(t, uint8(0), status(), "channel %d", i+1)
+
+		// Triggering again reloads the counter in full.
+		assert.NoError(t, a.poke(c.nrx4, gbNRx4Trigger|gbNRx4LengthEnable))
+		assert.Equal(t, c.maxLength, c.length)
+	}
+}
+
+// TestAPULengthDisabled tests that the length counter only counts down while
+// it's enabled.
+func TestAPULengthDisabled(t *testing.T) {
+	a := newGBAPU()
+	assert.NoError(t, a.poke(gbAddrNR11, gbLengthMax-1))
+	assert.NoError(t, a.poke(gbAddrNR14, gbNRx4Trigger))
+	a.seq.Tick(gbFrameSequencerCycles * gbFrameSequencerSteps)
+
+	assert.True(t, a.channels[0].enabled)
+	assert.Equal(t, 1, a.channels[0].length)
+}
+
+// TestAPUSweepDown tests a sweep that halves channel 1's frequency at each
+// sweep clock.
+func TestAPUSweepDown(t *testing.T) {
+	const sweepPeriod = 4 * gbFrameSequencerCycles // 128Hz
+
+	a := newGBAPU()
+	assert.NoError(t, a.poke(gbAddrNR10, 0x1<<gbNR10PeriodShift|gbNR10Negate|1))
+	assert.NoError(t, a.poke(gbAddrNR13, 0x00))
+	assert.NoError(t, a.poke(gbAddrNR14, gbNRx4Trigger|0x4)) // 1024
+
+	for _, expected := range []uint16{512, 256, 128, 64, 32, 16, 8, 4, 2, 1, 1} {
+		a.seq.Tick(sweepPeriod)
+		assert.Equal(t, expected, a.ch1Freq())
+		assert.True(t, a.channels[0].enabled)
+	}
+}
+
+// TestAPUSweepOverflow tests that channel 1 is turned off once a sweep would
+// take its frequency past 2047.
+func TestAPUSweepOverflow(t *testing.T) {
+	const sweepPeriod = 4 * gbFrameSequencerCycles // 128Hz
+
+	a := newGBAPU()
+	assert.NoError(t, a.poke(gbAddrNR10, 0x1<<gbNR10PeriodShift|1))
+	assert.NoError(t, a.poke(gbAddrNR13, 0x00))
+	assert.NoError(t, a.poke(gbAddrNR14, gbNRx4Trigger|0x3)) // 768
+
+	a.seq.Tick(sweepPeriod)
+	assert.Equal(t, uint16(1152), a.ch1Freq())
+	assert.True(t, a.channels[0].enabled)
+
+	// 1728 + 1728>>1 overflows, so the channel goes off with the sweep.
+	a.seq.Tick(sweepPeriod)
+	assert.Equal(t, uint16(1728), a.ch1Freq())
+	assert.False(t, a.channels[0].enabled)
+
+	val, err := a.read(gbAddrNR52)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0), val&0x1)
+}
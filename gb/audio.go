@@ -0,0 +1,75 @@
+package gb
+
+// gbAudioSampleRate is the default rate of the samples passed to the audio
+// callback.
+const gbAudioSampleRate = 44100
+
+// gbAudioOutput collects the APU's samples over a frame and hands them to the
+// audio callback, resampled to the output rate.
+type gbAudioOutput struct {
+	fn          func(left, right []float32)
+	left, right []float32 // native samples since the last frame
+	resamplers  [2]*AudioResampler
+	kept        float64 // fraction of a sample owed towards the next one kept
+}
+
+func newGBAudioOutput(rate int, fn func(left, right []float32)) *gbAudioOutput {
+	return &gbAudioOutput{
+		fn:    fn,
+		left:  make([]float32, 0, gbCyclesPerFrame),
+		right: make([]float32, 0, gbCyclesPerFrame),
+		resamplers: [2]*AudioResampler{
+			NewAudioResampler(gbClockHz, rate),
+			NewAudioResampler(gbClockHz, rate),
+		},
+	}
+}
+
+// push collects a sample. Above a speed of 1, samples are skipped so that
+// only 1 in every speed of them is kept.
+func (a *gbAudioOutput) push(left, right float32, speed float64) {
+	if speed > 1 {
+		a.kept += 1 / speed
+		if a.kept < 1 {
+			return
+		}
+		a.kept--
+	}
+
+	a.left = append(a.left, left)
+	a.right = append(a.right, right)
+}
+
+// flush resamples the samples collected so far and passes them to the
+// callback.
+func (a *gbAudioOutput) flush() {
+	left := a.resamplers[0].Resample(a.left)
+	right := a.resamplers[1].Resample(a.right)
+	a.left, a.right = a.left[:0], a.right[:0]
+
+	a.fn(left, right)
+}
+
+// WithAudioSampleRate sets the rate of the samples passed to the audio
+// callback, in samples per second. The default is 44100, which is also used
+// if the rate is below 1.
+func WithAudioSampleRate(rate int) Option {
+	return func(g *Gameboy) {
+		if rate < 1 {
+			rate = gbAudioSampleRate
+		}
+		g.audioRate = rate
+	}
+}
+
+// SetAudioCallback registers a callback that fires as the PPU enters V-blank
+// with the audio output since the last V-blank, resampled to the audio sample
+// rate. Both channels hold the same number of samples, roughly the sample
+// rate divided by the frame rate, and are only valid during the callback.
+// Passing nil stops audio from being collected.
+func (g *Gameboy) SetAudioCallback(fn func(left, right []float32)) {
+	g.audio = nil
+	if fn != nil {
+		g.audio = newGBAudioOutput(g.audioRate, fn)
+	}
+}
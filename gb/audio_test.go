@@ -0,0 +1,66 @@
+package gb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// gbSawtoothPeriod is the period of sawtoothAPU's wave in quartz-cycles.
+const gbSawtoothPeriod = 0x10000
+
+// sawtoothAPU is a fake APU that outputs a rising sawtooth wave on the left
+// channel and a falling one on the right.
+type sawtoothAPU struct {
+	cycles int
+}
+
+func (a *sawtoothAPU) tick() (left, right float32) {
+	val := float32(a.cycles%gbSawtoothPeriod) / gbSawtoothPeriod
+	a.cycles++
+	return val, -val
+}
+
+func (a *sawtoothAPU) snapshot() gbAPUState { return gbAPUState{} }
+func (a *sawtoothAPU) restore(gbAPUState)   {}
+
+// TestSetAudioCallback tests that the audio callback receives a frame's worth
+// of resampled audio at each V-blank.
+func TestSetAudioCallback(t *testing.T) {
+	g := prepareGameboy(t, testProgram)
+	g.apu = &sawtoothAPU{}
+
+	var left, right []float32
+	var counts []int
+	g.SetAudioCallback(func(l, r []float32) {
+		assert.Equal(t, len(l), len(r))
+		left = append(left, l...)
+		right = append(right, r...)
+		counts = append(counts, len(l))
+	})
+	runFrames(t, g, 4)
+
+	// The first V-blank comes partway through the first frame.
+	if !assert.Len(t, counts, 4) {
+		return
+	}
+	perFrame := float64(gbAudioSampleRate) * gbCyclesPerFrame / gbClockHz
+	for _, n := range counts[1:] {
+		assert.InDelta(t, perFrame, n, 1)
+	}
+
+	step := float64(gbClockHz) / gbAudioSampleRate
+	for i := range left {
+		pos := float64(i) * step
+		phase := pos - float64(int(pos/gbSawtoothPeriod)*gbSawtoothPeriod)
+		if phase > gbSawtoothPeriod-step {
+			continue // interpolated across the drop
+		}
+
+		expected := phase / gbSawtoothPeriod
+		if !assert.InDelta(t, expected, float64(left[i]), 1e-4, "sample %d", i) {
+			return
+		}
+		assert.InDelta(t, -expected, float64(right[i]), 1e-4, "sample %d", i)
+	}
+}
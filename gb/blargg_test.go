@@ -0,0 +1,61 @@
+package gb
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// gbTestROMsEnv names the environment variable pointing at a directory of
+// test ROMs. Tests that need them are skipped when it isn't set.
+const gbTestROMsEnv = "YAGE_TEST_ROMS"
+
+// loadTestROM returns a gameboy with the named ROM from the test ROM
+// directory loaded, skipping the test if there isn't a directory.
+func loadTestROM(t *testing.T, name string) *Gameboy {
+	dir := os.Getenv(gbTestROMsEnv)
+	if dir == "" {
+		t.Skipf("%s isn't set", gbTestROMsEnv)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := NewGameboy()
+	if err := g.LoadROM(data); err != nil {
+		t.Fatal(err)
+	}
+
+	return g
+}
+
+// TestBlarggCPUInstrs runs Blargg's cpu_instrs.gb, which tests every CPU
+// instruction and reports the results over the serial port.
+func TestBlarggCPUInstrs(t *testing.T) {
+	g := loadTestROM(t, "cpu_instrs.gb")
+
+	var out strings.Builder
+	g.SetSerialHandler(SerialHandlerFunc(func(b uint8) uint8 {
+		out.WriteByte(b)
+		return 0xFF
+	}))
+
+	// Run for up to 60 seconds of simulated time, stopping early once the
+	// ROM has reported a result.
+	frames := 60 * gbClockHz / gbCyclesPerFrame
+	for i := 0; i < frames; i++ {
+		if !assert.NoError(t, g.RunFrame(), out.String()) {
+			return
+		}
+		if s := out.String(); strings.Contains(s, "Passed") || strings.Contains(s, "Failed") {
+			break
+		}
+	}
+
+	assert.Contains(t, out.String(), "Passed")
+}
@@ -0,0 +1,247 @@
+package gb
+
+import "errors"
+
+var (
+	gbErrReadOnly = errors.New("gbMemoryBus: address is read-only")
+)
+
+// gbMemoryRegion maps an inclusive range of addresses to the hardware that
+// backs them. The hardware is passed the full address of each access.
+type gbMemoryRegion struct {
+	start, end uint32
+	mem        ram
+}
+
+// gbMemoryBus routes the CPU's memory accesses to the hardware backing each
+// address, and lets debuggers observe them through watchpoints. Addresses
+// that aren't mapped to a region fall through to flat RAM.
+type gbMemoryBus struct {
+	mem     *gbRAM
+	regions []gbMemoryRegion
+
+	// Individual addresses can be mapped to closures, which take priority
+	// over the regions.
+	readFuncs  map[uint16]func() uint8
+	writeFuncs map[uint16]func(uint8)
+
+	readWatches  map[uint16][]func(uint16, uint8)
+	writeWatches map[uint16][]func(uint16, uint8)
+
+	stats *MemoryStats // nil unless stats are enabled
+
+	// dmaActive is set while OAM DMA is running, which cuts off everything
+	// below the I/O registers. The I/O registers themselves stay reachable,
+	// as other hardware shares the bus to get at them.
+	dmaActive bool
+}
+
+func newGBMemoryBus() *gbMemoryBus {
+	return &gbMemoryBus{
+		mem: newGBRAM(),
+	}
+}
+
+// mapRegion routes accesses to the addresses from start to end inclusive to
+// the given hardware. Later mappings take priority over earlier ones.
+func (b *gbMemoryBus) mapRegion(start, end uint32, mem ram) {
+	b.regions = append(b.regions, gbMemoryRegion{start, end, mem})
+}
+
+// MapRead routes reads of the given address to fn, in place of whatever
+// hardware backs it. Passing a nil fn removes the mapping.
+func (b *gbMemoryBus) MapRead(addr uint16, fn func() uint8) {
+	if fn == nil {
+		delete(b.readFuncs, addr)
+		return
+	}
+
+	if b.readFuncs == nil {
+		b.readFuncs = make(map[uint16]func() uint8)
+	}
+	b.readFuncs[addr] = fn
+}
+
+// MapWrite routes writes to the given address to fn, in place of whatever
+// hardware backs it. Passing a nil fn removes the mapping.
+func (b *gbMemoryBus) MapWrite(addr uint16, fn func(uint8)) {
+	if fn == nil {
+		delete(b.writeFuncs, addr)
+		return
+	}
+
+	if b.writeFuncs == nil {
+		b.writeFuncs = make(map[uint16]func(uint8))
+	}
+	b.writeFuncs[addr] = fn
+}
+
+// gbReadOnlyRegion wraps hardware so that writes to it fail with
+// gbErrReadOnly, leaving the underlying memory untouched. Reads pass straight
+// through.
+type gbReadOnlyRegion struct {
+	mem ram
+}
+
+func newGBReadOnlyRegion(mem ram) *gbReadOnlyRegion {
+	return &gbReadOnlyRegion{mem: mem}
+}
+
+func (r *gbReadOnlyRegion) poke(addr uint32, val uint8) error {
+	return gbErrReadOnly
+}
+
+func (r *gbReadOnlyRegion) read(addr uint32) (uint8, error) {
+	return r.mem.read(addr)
+}
+
+func (r *gbReadOnlyRegion) ReadSlice(addr, n uint32) ([]uint8, error) {
+	return r.mem.ReadSlice(addr, n)
+}
+
+// gbLockedRegion wraps hardware that the PPU locks the CPU out of while it's
+// using it. While locked, reads return 0xFF and writes are ignored.
+type gbLockedRegion struct {
+	mem        ram
+	accessible func() bool
+}
+
+func newGBLockedRegion(mem ram, accessible func() bool) *gbLockedRegion {
+	return &gbLockedRegion{mem: mem, accessible: accessible}
+}
+
+func (r *gbLockedRegion) poke(addr uint32, val uint8) error {
+	if !r.accessible() {
+		return nil
+	}
+
+	return r.mem.poke(addr, val)
+}
+
+func (r *gbLockedRegion) read(addr uint32) (uint8, error) {
+	if !r.accessible() {
+		return 0xFF, nil
+	}
+
+	return r.mem.read(addr)
+}
+
+func (r *gbLockedRegion) ReadSlice(addr, n uint32) ([]uint8, error) {
+	return readN(r, addr, n)
+}
+
+// target returns the hardware backing the given address.
+func (b *gbMemoryBus) target(addr uint32) ram {
+	for i := len(b.regions) - 1; i >= 0; i-- {
+		if addr >= b.regions[i].start && addr <= b.regions[i].end {
+			return b.regions[i].mem
+		}
+	}
+
+	return b.mem
+}
+
+// AddReadWatch registers a callback that is invoked with the address and
+// value whenever the given address is read.
+func (b *gbMemoryBus) AddReadWatch(addr uint16, fn func(uint16, uint8)) {
+	if b.readWatches == nil {
+		b.readWatches = make(map[uint16][]func(uint16, uint8))
+	}
+	b.readWatches[addr] = append(b.readWatches[addr], fn)
+}
+
+// AddWriteWatch registers a callback that is invoked with the address and
+// value whenever the given address is written.
+func (b *gbMemoryBus) AddWriteWatch(addr uint16, fn func(uint16, uint8)) {
+	if b.writeWatches == nil {
+		b.writeWatches = make(map[uint16][]func(uint16, uint8))
+	}
+	b.writeWatches[addr] = append(b.writeWatches[addr], fn)
+}
+
+// dmaBlocked returns true if the given address is cut off by OAM DMA.
+func (b *gbMemoryBus) dmaBlocked(addr uint32) bool {
+	return b.dmaActive && addr < gbAddrIO
+}
+
+func (b *gbMemoryBus) poke(addr uint32, val uint8) error {
+	if b.dmaBlocked(addr) {
+		return nil
+	}
+
+	if fn, ok := b.writeFuncs[uint16(addr)]; ok && addr < gbMaxAddress {
+		fn(val)
+	} else if err := b.target(addr).poke(addr, val); err != nil {
+		return err
+	}
+
+	if b.stats != nil {
+		b.stats.recordWrite(addr)
+	}
+	for _, fn := range b.writeWatches[uint16(addr)] {
+		fn(uint16(addr), val)
+	}
+
+	return nil
+}
+
+func (b *gbMemoryBus) read(addr uint32) (uint8, error) {
+	if b.dmaBlocked(addr) {
+		return 0xFF, nil
+	}
+
+	var val uint8
+	if fn, ok := b.readFuncs[uint16(addr)]; ok && addr < gbMaxAddress {
+		val = fn()
+	} else {
+		var err error
+		if val, err = b.target(addr).read(addr); err != nil {
+			return 0, err
+		}
+	}
+
+	if b.stats != nil {
+		b.stats.recordRead(addr)
+	}
+	for _, fn := range b.readWatches[uint16(addr)] {
+		fn(uint16(addr), val)
+	}
+
+	return val, nil
+}
+
+func (b *gbMemoryBus) ReadSlice(addr, n uint32) ([]uint8, error) {
+	if n == 0 || !b.isFlat(addr, addr+n-1) {
+		return readN(b, addr, n)
+	}
+
+	return b.mem.ReadSlice(addr, n)
+}
+
+// isFlat returns true if the addresses from start to end inclusive are all
+// backed by flat RAM and none of them are being mapped, watched, counted or
+// cut off by DMA.
+func (b *gbMemoryBus) isFlat(start, end uint32) bool {
+	if b.stats != nil || b.dmaActive {
+		return false
+	}
+
+	for _, region := range b.regions {
+		if start <= region.end && end >= region.start {
+			return false
+		}
+	}
+
+	if len(b.readWatches) > 0 || len(b.readFuncs) > 0 {
+		for addr := start; addr <= end; addr++ {
+			if _, ok := b.readWatches[uint16(addr)]; ok {
+				return false
+			}
+			if _, ok := b.readFuncs[uint16(addr)]; ok {
+				return false
+			}
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,195 @@
+package gb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestBusWriteWatch tests that write watchpoints observe the PPU advancing
+// the LY register.
+func TestBusWriteWatch(t *testing.T) {
+	g := prepareGameboy(t, testProgram)
+
+	var lines []uint8
+	g.bus.AddWriteWatch(uint16(gbAddrLY), func(addr uint16, val uint8) {
+		assert.Equal(t, uint16(gbAddrLY), addr)
+		lines = append(lines, val)
+	})
+	runFrames(t, g, 1)
+
+	if !assert.Len(t, lines, gbLinesPerFrame) {
+		return
+	}
+	for i := 1; i < gbLinesPerFrame; i++ {
+		assert.Equal(t, uint8(i), lines[i-1])
+	}
+	assert.Equal(t, uint8(0), lines[gbLinesPerFrame-1])
+}
+
+// TestBusReadWatch tests that read watchpoints observe CPU reads.
+func TestBusReadWatch(t *testing.T) {
+	g := prepareGameboy(t, testProgram)
+
+	var reads []uint8
+	g.bus.AddReadWatch(0xC010, func(addr uint16, val uint8) {
+		reads = append(reads, val)
+	})
+	runInstructions(t, g, 5)
+
+	// Only the [LD B, (HL)] instruction reads from 0xC010.
+	assert.Equal(t, []uint8{0x42}, reads)
+}
+
+// TestBusReadSlice tests that slices read through the bus respect mapped
+// regions and watchpoints.
+func TestBusReadSlice(t *testing.T) {
+	g := NewGameboy()
+	assert.NoError(t, pokeN(g.bus, 0xFEFE, []uint8{0x12, 0x34}))
+
+	vals, err := g.bus.ReadSlice(0xFEFE, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint8{0x12, 0x34}, vals)
+
+	// The joypad register reads as 0xFF with nothing selected or pressed.
+	vals, err = g.bus.ReadSlice(0xFEFF, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint8{0x34, 0xFF}, vals)
+
+	var reads int
+	g.bus.AddReadWatch(0xFEFF, func(uint16, uint8) { reads++ })
+	_, err = g.bus.ReadSlice(0xFEFE, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, reads)
+
+	_, err = g.bus.ReadSlice(0xFFFF, 2)
+	assert.Equal(t, gbErrOutOfBounds, err)
+}
+
+// TestLoadReadsOpcodeBytes tests that fetching an opcode through a bus that
+// isn't flat only reads the opcode's own bytes.
+func TestLoadReadsOpcodeBytes(t *testing.T) {
+	c := newGBCPU()
+	b := newGBMemoryBus()
+	assert.NoError(t, pokeN(b, 0x100, []uint8{
+		0x00,       // 0x100: NOP
+		0x18, 0x00, // 0x101: JR 0
+		0x00, // 0x103: NOP
+	}))
+	c.pokeRegister(0x100, gbRegisterPC)
+
+	var reads []uint16
+	for addr := uint16(0x100); addr <= 0x104; addr++ {
+		b.AddReadWatch(addr, func(addr uint16, _ uint8) { reads = append(reads, addr) })
+	}
+	for i := 0; i < 2; i++ {
+		_, err := runInstructionCycle(c, b)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, []uint16{0x100, 0x101, 0x102}, reads)
+}
+
+// TestBusStats tests counting the memory accesses made by instructions.
+func TestBusStats(t *testing.T) {
+	c := newGBCPU()
+	b := newGBMemoryBus()
+	assert.NoError(t, pokeN(b, 0x100, []uint8{
+		0x26, 0xC0, // LD H, 0xC0
+		0x2E, 0x10, // LD L, 0x10
+		0x36, 0x42, // LD (HL), 0x42
+		0x46,       // LD B, (HL)
+		0x26, 0xFF, // LD H, 0xFF
+		0x2E, 0x80, // LD L, 0x80
+		0x70, // LD (HL), B
+	}))
+	c.pokeRegister(0x100, gbRegisterPC)
+
+	assert.Equal(t, MemoryStats{}, b.Stats())
+	b.EnableStats(true)
+	for i := 0; i < 7; i++ {
+		_, err := runInstructionCycle(c, b)
+		assert.NoError(t, err)
+	}
+
+	// Fetching reads each opcode's bytes from ROM: 2 each for the four
+	// LDs of an immediate, and 1 each for the other three.
+	assert.Equal(t, MemoryStats{
+		Reads:  12 + 1,
+		Writes: 2,
+		ROM:    RegionStats{Reads: 12},
+		WRAM:   RegionStats{Reads: 1, Writes: 1},
+		HRAM:   RegionStats{Writes: 1},
+	}, b.Stats())
+
+	b.EnableStats(false)
+	assert.NoError(t, b.poke(0xC000, 0x00))
+	assert.Equal(t, MemoryStats{}, b.Stats())
+}
+
+// TestReadOnlyRegion tests that writes to a read-only region fail without
+// modifying the memory underneath.
+func TestReadOnlyRegion(t *testing.T) {
+	b := newGBMemoryBus()
+	assert.NoError(t, b.poke(0x0100, 0x42))
+	b.mapRegion(0x0000, 0x7FFF, newGBReadOnlyRegion(b.mem))
+
+	assert.Equal(t, gbErrReadOnly, b.poke(0x0100, 0x24))
+	val, err := b.read(0x0100)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0x42), val)
+
+	// Addresses outside the region are still writable.
+	assert.NoError(t, b.poke(0x8000, 0x24))
+}
+
+// TestBusMapRead tests that addresses mapped to a read closure take priority
+// over the hardware backing them.
+func TestBusMapRead(t *testing.T) {
+	g := prepareGameboy(t, testProgram)
+	runFrames(t, g, 1)
+	assert.NoError(t, g.bus.poke(gbAddrLY, 0x10))
+
+	g.bus.MapRead(uint16(gbAddrLY), func() uint8 { return 0x99 })
+	val, err := g.bus.read(gbAddrLY)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0x99), val)
+
+	vals, err := g.bus.ReadSlice(gbAddrLY-1, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0x99), vals[1])
+}
+
+// TestBusMapWrite tests that addresses mapped to a write closure take
+// priority over the hardware backing them.
+func TestBusMapWrite(t *testing.T) {
+	b := newGBMemoryBus()
+
+	var writes []uint8
+	b.MapWrite(0xC000, func(val uint8) { writes = append(writes, val) })
+	assert.NoError(t, b.poke(0xC000, 0x42))
+	assert.NoError(t, b.poke(0xC001, 0x24))
+
+	assert.Equal(t, []uint8{0x42}, writes)
+	assert.Equal(t, uint8(0x00), b.mem.mem[0xC000])
+	assert.Equal(t, uint8(0x24), b.mem.mem[0xC001])
+}
+
+// TestBusUnmap tests that mapping an address to nil hands it back to the
+// hardware backing it.
+func TestBusUnmap(t *testing.T) {
+	b := newGBMemoryBus()
+
+	// Unmapping an address that was never mapped does nothing.
+	b.MapRead(0xC000, nil)
+	b.MapWrite(0xC000, nil)
+
+	b.MapRead(0xC000, func() uint8 { return 0x99 })
+	b.MapWrite(0xC000, func(uint8) {})
+	b.MapRead(0xC000, nil)
+	b.MapWrite(0xC000, nil)
+
+	assert.NoError(t, b.poke(0xC000, 0x42))
+	val, err := b.read(0xC000)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0x42), val)
+}
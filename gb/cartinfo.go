@@ -0,0 +1,113 @@
+package gb
+
+import "strings"
+
+const (
+	gbAddrCartTitle    uint32 = 0x0134 // cartridge header: game title
+	gbAddrCartTitleEnd uint32 = 0x0143
+	gbAddrCartCGBFlag  uint32 = 0x0143 // cartridge header: CGB support
+	gbAddrCartSGBFlag  uint32 = 0x0146 // cartridge header: SGB support
+	gbAddrCartROMSize  uint32 = 0x0148 // cartridge header: ROM size
+
+	gbCartCGBSupported uint8 = 0x1 << 7
+	gbCartCGBOnly      uint8 = 0xC0 // 0b11000000
+	gbCartSGBSupported uint8 = 0x03
+)
+
+// gbCartType describes the hardware for a cartridge type in the header.
+type gbCartType struct {
+	mbc     string
+	battery bool
+}
+
+// gbCartTypes maps the cartridge type byte of the header to the hardware it
+// describes, whether or not it's supported.
+var gbCartTypes = map[uint8]gbCartType{
+	0x00: {"ROM ONLY", false},
+	0x01: {"MBC1", false},
+	0x02: {"MBC1", false},
+	0x03: {"MBC1", true},
+	0x05: {"MBC2", false},
+	0x06: {"MBC2", true},
+	0x08: {"ROM ONLY", false},
+	0x09: {"ROM ONLY", true},
+	0x0B: {"MMM01", false},
+	0x0C: {"MMM01", false},
+	0x0D: {"MMM01", true},
+	0x0F: {"MBC3", true},
+	0x10: {"MBC3", true},
+	0x11: {"MBC3", false},
+	0x12: {"MBC3", false},
+	0x13: {"MBC3", true},
+	0x19: {"MBC5", false},
+	0x1A: {"MBC5", false},
+	0x1B: {"MBC5", true},
+	0x1C: {"MBC5", false},
+	0x1D: {"MBC5", false},
+	0x1E: {"MBC5", true},
+	0x20: {"MBC6", false},
+	0x22: {"MBC7", true},
+	0xFC: {"POCKET CAMERA", false},
+	0xFD: {"BANDAI TAMA5", false},
+	0xFE: {"HuC3", false},
+	0xFF: {"HuC1", true},
+}
+
+// CartridgeInfo is the metadata in a cartridge's header.
+type CartridgeInfo struct {
+	Title   string
+	MBC     string // memory bank controller, or "ROM ONLY"
+	Battery bool   // whether external RAM is kept when the power is off
+	ROMSize int    // in bytes, or 0 if the header's size is unknown
+	RAMSize int    // in bytes
+
+	CGBSupported bool // whether the game has CGB enhancements
+	CGBOnly      bool // whether the game only runs on a CGB
+	SGBSupported bool // whether the game has SGB enhancements
+}
+
+// CartridgeInfo returns the metadata in the loaded cartridge's header, or the
+// zero value if no ROM is loaded.
+func (g *Gameboy) CartridgeInfo() CartridgeInfo {
+	if g.header == nil {
+		return CartridgeInfo{}
+	}
+
+	h := g.header
+	cartType := gbCartTypes[h[gbAddrCartType]]
+	romSize, _ := ROMSizeFromHeader(h[gbAddrCartROMSize])
+	ramSize, _ := RAMSizeFromHeader(h[gbAddrCartRAMSize])
+	info := CartridgeInfo{
+		Title:        g.CartridgeTitle(),
+		MBC:          cartType.mbc,
+		Battery:      cartType.battery,
+		ROMSize:      romSize,
+		RAMSize:      ramSize,
+		CGBSupported: h[gbAddrCartCGBFlag]&gbCartCGBSupported != 0,
+		CGBOnly:      h[gbAddrCartCGBFlag] == gbCartCGBOnly,
+		SGBSupported: h[gbAddrCartSGBFlag] == gbCartSGBSupported,
+	}
+
+	// The MBC2's RAM is built in, so the header doesn't count it.
+	if cartType.mbc == "MBC2" {
+		info.RAMSize = gbMBC2RAMSize
+	}
+
+	return info
+}
+
+// CartridgeTitle returns the game title from the loaded cartridge's header,
+// or an empty string if no ROM is loaded. On CGB cartridges, the last byte
+// of the title is taken by the CGB flag.
+func (g *Gameboy) CartridgeTitle() string {
+	if g.header == nil {
+		return ""
+	}
+
+	end := gbAddrCartTitleEnd + 1
+	if g.header[gbAddrCartCGBFlag]&gbCartCGBSupported != 0 {
+		end = gbAddrCartCGBFlag
+	}
+
+	return strings.TrimRight(string(g.header[gbAddrCartTitle:end]), "\x00")
+}
@@ -0,0 +1,101 @@
+package gb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCartridgeInfo tests reading the metadata from a cartridge header.
+func TestCartridgeInfo(t *testing.T) {
+	rom := testROM(0x03, 4)
+	copy(rom[gbAddrCartTitle:], "POKEMON")
+	rom[gbAddrCartCGBFlag] = 0x80
+	rom[gbAddrCartSGBFlag] = 0x03
+	rom[gbAddrCartROMSize] = 0x01
+	rom[gbAddrCartRAMSize] = 0x03
+
+	g, err := NewGameboyFromROM(rom)
+	assert.NoError(t, err)
+	assert.Equal(t, CartridgeInfo{
+		Title:        "POKEMON",
+		MBC:          "MBC1",
+		Battery:      true,
+		ROMSize:      0x10000,
+		RAMSize:      0x8000,
+		CGBSupported: true,
+		CGBOnly:      false,
+		SGBSupported: true,
+	}, g.CartridgeInfo())
+
+	assert.Equal(t, CartridgeInfo{}, NewGameboy().CartridgeInfo())
+}
+
+// TestCartridgeInfoMBC2 tests that the MBC2's built-in RAM is reported.
+func TestCartridgeInfoMBC2(t *testing.T) {
+	rom := testROM(0x05, 2)
+	rom[gbAddrCartCGBFlag] = 0xC0
+
+	g, err := NewGameboyFromROM(rom)
+	assert.NoError(t, err)
+
+	info := g.CartridgeInfo()
+	assert.Equal(t, "MBC2", info.MBC)
+	assert.False(t, info.Battery)
+	assert.Equal(t, gbMBC2RAMSize, info.RAMSize)
+	assert.True(t, info.CGBSupported)
+	assert.True(t, info.CGBOnly)
+	assert.False(t, info.SGBSupported)
+}
+
+// TestCartridgeTitle tests that the CGB flag isn't read as part of the title.
+func TestCartridgeTitle(t *testing.T) {
+	rom := testROM(0x00, 2)
+	copy(rom[gbAddrCartTitle:], "ABCDEFGHIJKLMNOP")
+
+	g, err := NewGameboyFromROM(rom)
+	assert.NoError(t, err)
+	assert.Equal(t, "ABCDEFGHIJKLMNOP", g.CartridgeTitle())
+
+	rom[gbAddrCartCGBFlag] = 0x80
+	g, err = NewGameboyFromROM(rom)
+	assert.NoError(t, err)
+	assert.Equal(t, "ABCDEFGHIJKLMNO", g.CartridgeTitle())
+}
+
+// TestROMSizeFromHeader tests decoding the ROM size byte of the header.
+func TestROMSizeFromHeader(t *testing.T) {
+	for b := uint8(0x00); b <= 0x08; b++ {
+		size, err := ROMSizeFromHeader(b)
+		assert.NoError(t, err)
+		assert.Equal(t, (32*1024)<<b, size)
+	}
+
+	for b, expected := range map[uint8]int{0x52: 72, 0x53: 80, 0x54: 96} {
+		size, err := ROMSizeFromHeader(b)
+		assert.NoError(t, err)
+		assert.Equal(t, expected*gbROMBankSize, size)
+	}
+
+	_, err := ROMSizeFromHeader(0x09)
+	assert.Equal(t, gbErrUnknownROMSize, err)
+}
+
+// TestRAMSizeFromHeader tests decoding the RAM size byte of the header.
+func TestRAMSizeFromHeader(t *testing.T) {
+	for b, expected := range map[uint8]int{
+		0x00: 0,
+		0x01: 2 * 1024,
+		0x02: 8 * 1024,
+		0x03: 32 * 1024,
+		0x04: 128 * 1024,
+		0x05: 64 * 1024,
+	} {
+		size, err := RAMSizeFromHeader(b)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, size)
+	}
+
+	_, err := RAMSizeFromHeader(0x06)
+	assert.Equal(t, gbErrUnknownRAMSize, err)
+}
@@ -0,0 +1,253 @@
+package gb
+
+import (
+	"errors"
+	"io"
+)
+
+const (
+	gbAddrCartType    uint32 = 0x0147 // cartridge header: hardware type
+	gbAddrCartRAMSize uint32 = 0x0149 // cartridge header: external RAM size
+	gbCartHeaderEnd          = 0x0150
+
+	gbROMBankSize    = 0x4000 // 16Kb
+	gbExtRAMBankSize = 0x2000 // 8Kb
+)
+
+var (
+	gbErrROMTooSmall          = errors.New("gbCartridge: ROM is too small to hold a header")
+	gbErrROMLoaded            = errors.New("gbCartridge: a ROM has already been loaded")
+	gbErrUnsupportedCartridge = errors.New("gbCartridge: cartridge type isn't supported")
+	gbErrCartridgeAddress     = errors.New("gbCartridge: address isn't ROM or external RAM")
+	gbErrUnknownROMSize       = errors.New("gbCartridge: header has an unknown ROM size")
+	gbErrUnknownRAMSize       = errors.New("gbCartridge: header has an unknown RAM size")
+)
+
+// gbCartROMSizes maps the ROM size byte of the cartridge header to the size of
+// the ROM.
+var gbCartROMSizes = map[uint8]int{
+	0x00: 0x8000, // 32Kb, 2 banks
+	0x01: 0x10000,
+	0x02: 0x20000,
+	0x03: 0x40000,
+	0x04: 0x80000,
+	0x05: 0x100000,
+	0x06: 0x200000,
+	0x07: 0x400000,
+	0x08: 0x800000, // 8Mb, 512 banks
+	0x52: 0x120000, // unofficial, 72 banks
+	0x53: 0x140000, // unofficial, 80 banks
+	0x54: 0x180000, // unofficial, 96 banks
+}
+
+// gbCartRAMSizes maps the RAM size byte of the cartridge header to the amount
+// of external RAM on the cartridge.
+var gbCartRAMSizes = map[uint8]int{
+	0x00: 0,
+	0x01: 0x800, // unofficial
+	0x02: 0x2000,
+	0x03: 0x8000,
+	0x04: 0x20000,
+	0x05: 0x10000,
+}
+
+// cartridge is the hardware on a game cartridge, mapped to the ROM and
+// external RAM regions of memory.
+type cartridge interface {
+	ram
+
+	// CurrentROMBank returns the ROM bank mapped to 0x4000-0x7FFF, and
+	// BankSwitchCount the number of times it has been switched, for checking
+	// that a game's banking is being applied.
+	CurrentROMBank() int
+	BankSwitchCount() int
+
+	// reset returns the cartridge to its power-on state. External RAM is
+	// left alone, as it may be battery-backed.
+	reset()
+
+	// snapshot returns the cartridge's internal state, including external
+	// RAM, for save states, and restore loads it back.
+	snapshot() []uint8
+	restore([]uint8) error
+}
+
+// newCartridge returns the cartridge hardware described by the ROM's header.
+func newCartridge(data []uint8) (cartridge, error) {
+	if len(data) < gbCartHeaderEnd {
+		return nil, gbErrROMTooSmall
+	}
+
+	// Pad the ROM out to a whole number of banks, and at least two, so that
+	// every bank can be read in full.
+	banks := (len(data) + gbROMBankSize - 1) / gbROMBankSize
+	if banks < 2 {
+		banks = 2
+	}
+	rom := make([]uint8, banks*gbROMBankSize)
+	for i := copy(rom, data); i < len(rom); i++ {
+		rom[i] = 0xFF
+	}
+
+	ramSize, err := RAMSizeFromHeader(rom[gbAddrCartRAMSize])
+	if err != nil {
+		return nil, err
+	}
+
+	switch rom[gbAddrCartType] {
+	case 0x00:
+		return &romOnlyCartridge{rom: rom}, nil
+
+	case 0x01:
+		return newMBC1Cartridge(rom, 0, false), nil
+
+	case 0x02:
+		return newMBC1Cartridge(rom, ramSize, false), nil
+
+	case 0x03:
+		return newMBC1Cartridge(rom, ramSize, true), nil
+
+	case 0x05, 0x06:
+		return newMBC2Cartridge(rom), nil
+	}
+
+	return nil, gbErrUnsupportedCartridge
+}
+
+// ROMSizeFromHeader returns the size in bytes of the ROM described by the ROM
+// size byte of a cartridge header.
+func ROMSizeFromHeader(b uint8) (int, error) {
+	size, ok := gbCartROMSizes[b]
+	if !ok {
+		return 0, gbErrUnknownROMSize
+	}
+
+	return size, nil
+}
+
+// RAMSizeFromHeader returns the size in bytes of the external RAM described by
+// the RAM size byte of a cartridge header.
+func RAMSizeFromHeader(b uint8) (int, error) {
+	size, ok := gbCartRAMSizes[b]
+	if !ok {
+		return 0, gbErrUnknownRAMSize
+	}
+
+	return size, nil
+}
+
+// romSlice returns n bytes of rom starting at offset, provided they don't
+// cross the end of the bank.
+func romSlice(rom []uint8, offset, n uint32) ([]uint8, bool) {
+	if offset%gbROMBankSize+n > gbROMBankSize {
+		return nil, false
+	}
+
+	return rom[offset : offset+n], true
+}
+
+// romOnlyCartridge is a plain 32Kb ROM, with no banking or external RAM.
+type romOnlyCartridge struct {
+	rom []uint8
+}
+
+func (c *romOnlyCartridge) poke(addr uint32, val uint8) error {
+	if addr > gbAddrROMEnd && (addr < gbAddrExtRAM || addr > gbAddrExtRAMEnd) {
+		return gbErrCartridgeAddress
+	}
+
+	return nil // writes are ignored
+}
+
+func (c *romOnlyCartridge) read(addr uint32) (uint8, error) {
+	switch {
+	case addr <= gbAddrROMEnd:
+		return c.rom[addr], nil
+
+	case addr >= gbAddrExtRAM && addr <= gbAddrExtRAMEnd:
+		return 0xFF, nil
+	}
+
+	return 0, gbErrCartridgeAddress
+}
+
+func (c *romOnlyCartridge) ReadSlice(addr, n uint32) ([]uint8, error) {
+	if addr+n-1 <= gbAddrROMEnd {
+		if s, ok := romSlice(c.rom, addr, n); ok {
+			return s, nil
+		}
+	}
+
+	return readN(c, addr, n)
+}
+
+func (c *romOnlyCartridge) CurrentROMBank() int {
+	return 1
+}
+
+func (c *romOnlyCartridge) BankSwitchCount() int {
+	return 0
+}
+
+func (c *romOnlyCartridge) reset() {}
+
+func (c *romOnlyCartridge) snapshot() []uint8 {
+	return nil
+}
+
+func (c *romOnlyCartridge) restore([]uint8) error {
+	return nil
+}
+
+// LoadROMFromReader reads a whole ROM from r, ready to be passed to LoadROM.
+// The ROM must be big enough to hold a cartridge header.
+func LoadROMFromReader(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < gbCartHeaderEnd {
+		return nil, gbErrROMTooSmall
+	}
+
+	return data, nil
+}
+
+// LoadROM inserts a cartridge with the given ROM into the gameboy. Only one
+// cartridge can be loaded.
+func (g *Gameboy) LoadROM(data []uint8) error {
+	if g.cart != nil {
+		return gbErrROMLoaded
+	}
+
+	cart, err := newCartridge(data)
+	if err != nil {
+		return err
+	}
+
+	// Without a memory bank controller there's nothing listening for writes
+	// to ROM, so they're caught as bugs.
+	var rom ram = cart
+	if _, ok := cart.(*romOnlyCartridge); ok {
+		rom = newGBReadOnlyRegion(cart)
+	}
+
+	g.cart = cart
+	g.header = append([]uint8(nil), data[:gbCartHeaderEnd]...)
+	g.bus.mapRegion(gbAddrROMBank0, gbAddrROMEnd, rom)
+	g.bus.mapRegion(gbAddrExtRAM, gbAddrExtRAMEnd, cart)
+
+	return nil
+}
+
+// NewGameboyFromROM returns a gameboy with the given options and a cartridge
+// with the given ROM loaded.
+func NewGameboyFromROM(data []uint8, opts ...Option) (*Gameboy, error) {
+	g := NewGameboy(opts...)
+	if err := g.LoadROM(data); err != nil {
+		return nil, err
+	}
+
+	return g, nil
+}
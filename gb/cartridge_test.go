@@ -0,0 +1,269 @@
+package gb
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testROM returns a ROM of the given cartridge type with the given number of
+// banks, where every byte of each bank holds the bank's number.
+func testROM(cartType uint8, banks int) []uint8 {
+	rom := make([]uint8, banks*gbROMBankSize)
+	for i := range rom {
+		rom[i] = uint8(i / gbROMBankSize)
+	}
+	rom[gbAddrCartType] = cartType
+	rom[gbAddrCartRAMSize] = 0x00
+
+	return rom
+}
+
+// TestLoadROM tests mapping a ROM-only cartridge into memory.
+func TestLoadROM(t *testing.T) {
+	g := NewGameboy()
+	assert.NoError(t, g.LoadROM(testROM(0x00, 2)))
+	assert.Equal(t, gbErrROMLoaded, g.LoadROM(testROM(0x00, 2)))
+
+	val, err := g.bus.read(0x4000)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(1), val)
+
+	// Writes to ROM fail, leaving it untouched.
+	assert.Equal(t, gbErrReadOnly, g.bus.poke(0x4000, 0x42))
+	val, err = g.bus.read(0x4000)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(1), val)
+
+	assert.Equal(t, gbErrROMTooSmall, NewGameboy().LoadROM(make([]uint8, 0x100)))
+	assert.Equal(t, gbErrUnsupportedCartridge, NewGameboy().LoadROM(testROM(0xFC, 2)))
+}
+
+// TestNewGameboyFromROM tests creating a gameboy with a cartridge loaded.
+func TestNewGameboyFromROM(t *testing.T) {
+	rom := testROM(0x00, 2)
+	copy(rom[gbAddrCartTitle:], "TETRIS")
+
+	g, err := NewGameboyFromROM(rom, WithModel(CGB))
+	assert.NoError(t, err)
+	assert.Equal(t, "TETRIS", g.CartridgeTitle())
+	assert.Equal(t, CGB, g.model)
+
+	_, err = NewGameboyFromROM(rom[:gbCartHeaderEnd-1])
+	assert.Equal(t, gbErrROMTooSmall, err)
+	assert.Equal(t, "", NewGameboy().CartridgeTitle())
+}
+
+// TestLoadROMFromReader tests reading a ROM from a stream.
+func TestLoadROMFromReader(t *testing.T) {
+	rom := testROM(0x00, 2)
+	data, err := LoadROMFromReader(bytes.NewReader(rom))
+	assert.NoError(t, err)
+	assert.Equal(t, rom, data)
+
+	_, err = LoadROMFromReader(bytes.NewReader(rom[:gbCartHeaderEnd-1]))
+	assert.Equal(t, gbErrROMTooSmall, err)
+}
+
+// TestMBC1ROMBanking tests switching ROM banks into 0x4000-0x7FFF.
+func TestMBC1ROMBanking(t *testing.T) {
+	g := NewGameboy()
+	assert.NoError(t, g.LoadROM(testROM(0x01, 64)))
+
+	cases := []struct {
+		bank1, bank2 uint8
+		expected     uint8
+	}{
+		{0x00, 0x00, 1}, // bank 0 maps to bank 1
+		{0x05, 0x00, 5},
+		{0x1F, 0x00, 31},
+		{0x01, 0x01, 33},
+		{0x01, 0x02, 1}, // wraps around the 64 banks
+	}
+
+	for _, c := range cases {
+		assert.NoError(t, g.bus.poke(0x2000, c.bank1))
+		assert.NoError(t, g.bus.poke(0x4000, c.bank2))
+
+		val, err := g.bus.read(0x4000)
+		assert.NoError(t, err)
+		assert.Equal(t, c.expected, val)
+
+		val, err = g.bus.read(0x0000)
+		assert.NoError(t, err)
+		assert.Equal(t, uint8(0), val)
+	}
+}
+
+// TestMBC1BankZero tests that banks with zero in the lower 5 bits can't be
+// mapped to 0x4000-0x7FFF.
+func TestMBC1BankZero(t *testing.T) {
+	g := NewGameboy()
+	assert.NoError(t, g.LoadROM(testROM(0x01, 128)))
+
+	for bank2, expected := range []int{0x01, 0x21, 0x41, 0x61} {
+		assert.NoError(t, g.bus.poke(0x4000, uint8(bank2)))
+		assert.NoError(t, g.bus.poke(0x2000, 0x00))
+		assert.Equal(t, expected, g.cart.CurrentROMBank())
+	}
+
+	assert.Equal(t, uint8(0x21), validateMBC1BankNumber(0x20))
+	assert.Equal(t, uint8(0x22), validateMBC1BankNumber(0x22))
+}
+
+// TestMBC1RAMBanking tests switching RAM banks in banking mode 1.
+func TestMBC1RAMBanking(t *testing.T) {
+	rom := testROM(0x03, 4)
+	rom[gbAddrCartRAMSize] = 0x03 // 4 banks of 8Kb
+	g := NewGameboy()
+	assert.NoError(t, g.LoadROM(rom))
+	assert.NoError(t, g.bus.poke(0x0000, 0x0A))
+
+	// In mode 0, RAM is always bank 0.
+	assert.NoError(t, g.bus.poke(0x4000, 0x01))
+	assert.NoError(t, g.bus.poke(0xA000, 0x11))
+	assert.Equal(t, 0, g.cart.(*mbc1Cartridge).RAMBankSwitchCount())
+
+	assert.NoError(t, g.bus.poke(0x6000, 0x01))
+	assert.NoError(t, g.bus.poke(0xA000, 0x42))
+	assert.NoError(t, g.bus.poke(0x4000, 0x02))
+	assert.NoError(t, g.bus.poke(0xA000, 0x24))
+	assert.NoError(t, g.bus.poke(0x4000, 0x01))
+
+	val, err := g.bus.read(0xA000)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0x42), val)
+
+	// Back in mode 0, bank 0 is untouched.
+	assert.NoError(t, g.bus.poke(0x6000, 0x00))
+	val, err = g.bus.read(0xA000)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0x11), val)
+	assert.Equal(t, 4, g.cart.(*mbc1Cartridge).RAMBankSwitchCount())
+}
+
+// TestMBC1RAMEnable tests that external RAM can only be accessed while it's
+// enabled, and keeps its contents while it isn't.
+func TestMBC1RAMEnable(t *testing.T) {
+	rom := testROM(0x03, 4)
+	rom[gbAddrCartRAMSize] = 0x02 // 1 bank of 8Kb
+	g := NewGameboy()
+	assert.NoError(t, g.LoadROM(rom))
+
+	readRAM := func() uint8 {
+		val, err := g.bus.read(0xA000)
+		assert.NoError(t, err)
+		return val
+	}
+
+	assert.NoError(t, g.bus.poke(0x0000, 0x0A))
+	assert.NoError(t, g.bus.poke(0xA000, 0x42))
+	assert.Equal(t, uint8(0x42), readRAM())
+
+	assert.NoError(t, g.bus.poke(0x1FFF, 0x00))
+	assert.Equal(t, uint8(0xFF), readRAM())
+	assert.NoError(t, g.bus.poke(0xA000, 0x24))
+
+	assert.NoError(t, g.bus.poke(0x1FFF, 0x1A)) // only the low nibble counts
+	assert.Equal(t, uint8(0x42), readRAM())
+}
+
+// TestMBC1Types tests the RAM and battery of each MBC1 cartridge type.
+func TestMBC1Types(t *testing.T) {
+	testFn := func(cartType uint8, hasRAM, battery bool) func(*testing.T) {
+		return func(t *testing.T) {
+			rom := testROM(cartType, 4)
+			rom[gbAddrCartRAMSize] = 0x02 // 1 bank of 8Kb
+			g := NewGameboy()
+			assert.NoError(t, g.LoadROM(rom))
+			assert.NoError(t, g.bus.poke(0x0000, 0x0A))
+			assert.Equal(t, battery, g.cart.(*mbc1Cartridge).battery)
+
+			expected := uint8(0xFF)
+			if hasRAM {
+				expected = 0x42
+			}
+			assert.NoError(t, g.bus.poke(0xA000, 0x42))
+			val, err := g.bus.read(0xA000)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, val)
+		}
+	}
+
+	t.Run("MBC1", testFn(0x01, false, false))
+	t.Run("MBC1+RAM", testFn(0x02, true, false))
+	t.Run("MBC1+RAM+BATTERY", testFn(0x03, true, true))
+}
+
+// TestMBC1BankSwitchCount tests counting the switches between ROM banks.
+func TestMBC1BankSwitchCount(t *testing.T) {
+	g := NewGameboy()
+	assert.NoError(t, g.LoadROM(testROM(0x01, 64)))
+	assert.Equal(t, 1, g.cart.CurrentROMBank())
+
+	// Selecting the bank that's already mapped isn't a switch.
+	for _, bank := range []uint8{0x01, 0x05, 0x05, 0x00, 0x1F} {
+		assert.NoError(t, g.bus.poke(0x2000, bank))
+	}
+	assert.NoError(t, g.bus.poke(0x4000, 0x01))
+
+	assert.Equal(t, 4, g.cart.BankSwitchCount())
+	assert.Equal(t, 63, g.cart.CurrentROMBank())
+	assert.Equal(t, 0, g.cart.(*mbc1Cartridge).RAMBankSwitchCount())
+}
+
+// TestMBC2ROMBanking tests switching ROM banks, which only happens for
+// register writes with bit 8 of the address set.
+func TestMBC2ROMBanking(t *testing.T) {
+	g := NewGameboy()
+	assert.NoError(t, g.LoadROM(testROM(0x05, 16)))
+
+	assert.NoError(t, g.bus.poke(0x2000, 0x05)) // bit 8 clear
+	assert.Equal(t, 1, g.cart.CurrentROMBank())
+
+	assert.NoError(t, g.bus.poke(0x2100, 0x05))
+	val, err := g.bus.read(0x4000)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(5), val)
+
+	assert.NoError(t, g.bus.poke(0x0100, 0x00)) // bank 0 maps to bank 1
+	assert.Equal(t, 1, g.cart.CurrentROMBank())
+	assert.Equal(t, 2, g.cart.BankSwitchCount())
+}
+
+// TestMBC2RAM tests the 4-bit internal RAM and its mirroring.
+func TestMBC2RAM(t *testing.T) {
+	g := NewGameboy()
+	assert.NoError(t, g.LoadROM(testROM(0x06, 2)))
+
+	// RAM is disabled at power-on.
+	assert.NoError(t, g.bus.poke(0xA000, 0x05))
+	val, err := g.bus.read(0xA000)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0xFF), val)
+
+	assert.NoError(t, g.bus.poke(0x0000, 0x0A))
+	assert.NoError(t, g.bus.poke(0xA000, 0x35))
+	assert.NoError(t, g.bus.poke(0xA1FF, 0x0C))
+
+	val, err = g.bus.read(0xA000)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0x05), val&0x0F)
+
+	// 0xA200-0xBFFF mirrors 0xA000-0xA1FF.
+	for _, addr := range []uint32{0xA200, 0xB000, 0xBE00} {
+		val, err = g.bus.read(addr)
+		assert.NoError(t, err)
+		assert.Equal(t, uint8(0x05), val&0x0F)
+	}
+	assert.NoError(t, g.bus.poke(0xBFFF, 0x07))
+	val, err = g.bus.read(0xA1FF)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0x07), val&0x0F)
+
+	assert.NoError(t, g.bus.poke(0x0000, 0x00))
+	val, err = g.bus.read(0xA000)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0xFF), val)
+}
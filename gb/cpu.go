@@ -2,6 +2,8 @@ package gb
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 )
 
 type cpu interface {
@@ -11,8 +13,10 @@ type cpu interface {
 	load(ram) (*gbOpcode, error)
 
 	// execute performs the given opcode, updating memory, registers and
-	// peripherals as needed.
-	execute(ram, *gbOpcode) error
+	// peripherals as needed. It returns the number of cycles the opcode took,
+	// in units of 4 quartz-cycles, which for conditional opcodes depends on
+	// whether the condition held.
+	execute(ram, *gbOpcode) (int, error)
 
 	// readRegister returns the value in the given register. If the register is
 	// 8-bit, the least-significant bits hold the actual value of the register.
@@ -21,6 +25,26 @@ type cpu interface {
 	// pokeRegister assigns the given value to the given register. If the register
 	// is 8-bit, the least-significant bits of the value are assigned to it.
 	pokeRegister(uint16, gbRegisterType)
+
+	// DumpRegisters returns a human-readable listing of the register values
+	// and flags, for debugging.
+	DumpRegisters() string
+
+	// SetBreakpoint registers a hook to call before executing the instruction
+	// at the given address. A nil hook clears the breakpoint.
+	SetBreakpoint(uint16, func(*gbCPU))
+
+	// DoubleSpeed returns true if the CPU is running in CGB double-speed mode.
+	DoubleSpeed() bool
+
+	// isHalted returns true if the CPU is halted, waiting for an interrupt.
+	// Once an interrupt is pending the CPU wakes up, and it returns false.
+	isHalted(ram) (bool, error)
+
+	// snapshot returns the CPU's internal state, other than its registers,
+	// for save states, and restore loads it back.
+	snapshot() gbCPUState
+	restore(gbCPUState)
 }
 
 type gbRegisterType int
@@ -49,6 +73,11 @@ const (
 	gbRegisterBC gbRegisterType = 12
 	gbRegisterDE gbRegisterType = 13
 	gbRegisterHL gbRegisterType = 14
+
+	// gbRegisterHLIndirect stands in for the byte in memory at the address
+	// in HL, which opcodes encode in the same way as the 8-bit registers. It
+	// isn't a real register, so it can't be read or poked.
+	gbRegisterHLIndirect gbRegisterType = 15
 )
 
 var (
@@ -67,6 +96,43 @@ func (rt gbRegisterType) isCombined() bool {
 	return rt >= gbRegisterAF && rt <= gbRegisterHL
 }
 
+func (rt gbRegisterType) String() string {
+	switch rt {
+	case gbRegisterA:
+		return "A"
+	case gbRegisterF:
+		return "F"
+	case gbRegisterB:
+		return "B"
+	case gbRegisterC:
+		return "C"
+	case gbRegisterD:
+		return "D"
+	case gbRegisterE:
+		return "E"
+	case gbRegisterH:
+		return "H"
+	case gbRegisterL:
+		return "L"
+	case gbRegisterSP:
+		return "SP"
+	case gbRegisterPC:
+		return "PC"
+	case gbRegisterAF:
+		return "AF"
+	case gbRegisterBC:
+		return "BC"
+	case gbRegisterDE:
+		return "DE"
+	case gbRegisterHL:
+		return "HL"
+	case gbRegisterHLIndirect:
+		return "(HL)"
+	}
+
+	return "UNKNOWN"
+}
+
 func decodeRegisterType(t uint8) gbRegisterType {
 	t = t & 0x7 // use only 3 least-significant bits
 
@@ -89,6 +155,9 @@ func decodeRegisterType(t uint8) gbRegisterType {
 	case 5: // 0b101
 		return gbRegisterL
 
+	case 6: // 0b110
+		return gbRegisterHLIndirect
+
 	case 7: // 0b111
 		return gbRegisterA
 	}
@@ -96,6 +165,21 @@ func decodeRegisterType(t uint8) gbRegisterType {
 	return gbRegisterUnknown
 }
 
+// decodeStackRegisterType decodes the 16-bit register encoded in PUSH and POP
+// opcodes, which use AF in place of SP.
+func decodeStackRegisterType(qq uint8) gbRegisterType {
+	switch qq & 0x3 {
+	case 0: // 0b00
+		return gbRegisterBC
+	case 1: // 0b01
+		return gbRegisterDE
+	case 2: // 0b10
+		return gbRegisterHL
+	}
+
+	return gbRegisterAF
+}
+
 const (
 	gbFlagCarry     uint8 = 0x1 << 4
 	gbFlagHalfCarry uint8 = 0x1 << 5
@@ -103,9 +187,47 @@ const (
 	gbFlagZero      uint8 = 0x1 << 7
 )
 
+// gbConditionNames holds the assembly names of the branch conditions, indexed
+// by their 2-bit encoding.
+var gbConditionNames = [4]string{"NZ", "Z", "NC", "C"}
+
+// conditionHolds reports whether the branch condition encoded in the 2
+// least-significant bits of cc is satisfied by the flag register.
+func conditionHolds(c cpu, cc uint8) bool {
+	f := uint8(c.readRegister(gbRegisterF))
+
+	switch cc & 0x3 {
+	case 0: // 0b00
+		return f&gbFlagZero == 0
+
+	case 1: // 0b01
+		return f&gbFlagZero != 0
+
+	case 2: // 0b10
+		return f&gbFlagCarry == 0
+	}
+
+	return f&gbFlagCarry != 0
+}
+
 type gbCPU struct {
 	reg8  [8]uint8  // semantically a map[gbRegisterType]uint8
 	reg16 [2]uint16 // semantically a map[gbRegisterType]uint16
+
+	cgb bool // whether gameboy color only instructions are permitted
+
+	doubleSpeed bool // running at twice the clock speed (CGB only)
+	speedArmed  bool // the next STOP switches speed (CGB only)
+
+	ime        bool // interrupt master enable
+	pendingIME bool // EI was just executed, so IME is set after this opcode
+	halted     bool // waiting for an interrupt after HALT
+	haltBug    bool // the next opcode's first byte is read twice
+
+	totalCycles uint64   // cycles spent executing opcodes, 4 per machine cycle
+	bus         gbCPUBus // the memory the current opcode executes against
+
+	breakpoints map[uint16]func(*gbCPU)
 }
 
 var (
@@ -118,13 +240,79 @@ func newGBCPU() *gbCPU {
 	return &gbCPU{}
 }
 
+func (c *gbCPU) DoubleSpeed() bool {
+	return c.doubleSpeed
+}
+
+// gbCPUState is the serialisable internal state of a gbCPU, other than its
+// registers.
+type gbCPUState struct {
+	IME, PendingIME, Halted, HaltBug bool
+	TotalCycles                      uint64
+}
+
+func (c *gbCPU) snapshot() gbCPUState {
+	return gbCPUState{
+		IME:         c.ime,
+		PendingIME:  c.pendingIME,
+		Halted:      c.halted,
+		HaltBug:     c.haltBug,
+		TotalCycles: c.totalCycles,
+	}
+}
+
+func (c *gbCPU) restore(s gbCPUState) {
+	c.ime, c.pendingIME = s.IME, s.PendingIME
+	c.halted, c.haltBug = s.Halted, s.HaltBug
+	c.totalCycles = s.TotalCycles
+}
+
+// TotalCycles returns the number of clock cycles the CPU has spent executing
+// opcodes. Each takes 4 cycles for every byte of memory it reads or writes,
+// including fetching the opcode itself, and for every internal step. They're
+// counted as the accesses and steps happen, so they add up to 4 times the
+// machine cycles execute returns.
+func (c *gbCPU) TotalCycles() uint64 {
+	return c.totalCycles
+}
+
+func (c *gbCPU) isHalted(r ram) (bool, error) {
+	if !c.halted {
+		return false, nil
+	}
+
+	// TODO(guy): Service the interrupt if IME is set.
+	pending, err := pendingInterrupts(r)
+	if err != nil {
+		return false, err
+	}
+	c.halted = pending == 0
+
+	return c.halted, nil
+}
+
+// SetBreakpoint registers a hook that is called whenever the CPU is about to
+// execute the instruction at the given address. Setting a nil hook clears the
+// breakpoint.
+func (c *gbCPU) SetBreakpoint(addr uint16, fn func(*gbCPU)) {
+	if fn == nil {
+		delete(c.breakpoints, addr)
+		return
+	}
+
+	if c.breakpoints == nil {
+		c.breakpoints = make(map[uint16]func(*gbCPU))
+	}
+	c.breakpoints[addr] = fn
+}
+
 func (c *gbCPU) readRegister(t gbRegisterType) uint16 {
 	if t.is8Bit() {
 		return uint16(c.reg8[t-1])
 	}
 
 	if t.is16Bit() && !t.isCombined() {
-		return c.reg16[t-gbRegisterSP-1]
+		return c.reg16[t-gbRegisterSP]
 	}
 
 	switch t {
@@ -146,7 +334,6 @@ func (c *gbCPU) readRegister(t gbRegisterType) uint16 {
 	}
 
 	panic(gbErrUnknownRegisterType) // should never get here
-	return 0
 }
 
 func (c *gbCPU) pokeRegister(val uint16, t gbRegisterType) {
@@ -156,7 +343,7 @@ func (c *gbCPU) pokeRegister(val uint16, t gbRegisterType) {
 	}
 
 	if t.is16Bit() && !t.isCombined() {
-		c.reg16[t-gbRegisterSP-1] = val
+		c.reg16[t-gbRegisterSP] = val
 		return
 	}
 
@@ -185,125 +372,362 @@ func (c *gbCPU) pokeRegister(val uint16, t gbRegisterType) {
 	panic(gbErrUnknownRegisterType) // should never get here
 }
 
+// pushStack decrements SP by two and writes val to the new top of the stack.
+// SP is left untouched if the write fails.
+func (c *gbCPU) pushStack(r ram, val uint16) error {
+	sp := c.readRegister(gbRegisterSP) - 2
+	if err := pokeWord(r, uint32(sp), val); err != nil {
+		return err
+	}
+
+	c.pokeRegister(sp, gbRegisterSP)
+	return nil
+}
+
+// popStack reads the word at the top of the stack and increments SP by two.
+// SP is left untouched if the read fails.
+func (c *gbCPU) popStack(r ram) (uint16, error) {
+	sp := c.readRegister(gbRegisterSP)
+	val, err := readWord(r, uint32(sp))
+	if err != nil {
+		return 0, err
+	}
+
+	c.pokeRegister(sp+2, gbRegisterSP)
+	return val, nil
+}
+
+// DumpRegisters returns a human-readable listing of the register values and
+// flags, for debugging.
+func (c *gbCPU) DumpRegisters() string {
+	f := uint8(c.readRegister(gbRegisterF))
+	flag := func(mask uint8) int {
+		if f&mask != 0 {
+			return 1
+		}
+		return 0
+	}
+
+	return fmt.Sprintf("AF=0x%04X BC=0x%04X DE=0x%04X HL=0x%04X\n"+
+		"SP=0x%04X PC=0x%04X\n"+
+		"Z=%d N=%d H=%d C=%d",
+		c.readRegister(gbRegisterAF), c.readRegister(gbRegisterBC),
+		c.readRegister(gbRegisterDE), c.readRegister(gbRegisterHL),
+		c.readRegister(gbRegisterSP), c.readRegister(gbRegisterPC),
+		flag(gbFlagZero), flag(gbFlagSubtract), flag(gbFlagHalfCarry),
+		flag(gbFlagCarry))
+}
+
+// CPUSnapshot is a copy of every register in a CPU, which can be compared
+// against another in one go.
+type CPUSnapshot struct {
+	A, F, B, C, D, E, H, L uint8
+	SP, PC                 uint16
+}
+
+// NewCPUSnapshot copies the registers of the given CPU.
+func NewCPUSnapshot(c cpu) CPUSnapshot {
+	r8 := func(rt gbRegisterType) uint8 {
+		return uint8(c.readRegister(rt))
+	}
+
+	return CPUSnapshot{
+		A: r8(gbRegisterA), F: r8(gbRegisterF),
+		B: r8(gbRegisterB), C: r8(gbRegisterC),
+		D: r8(gbRegisterD), E: r8(gbRegisterE),
+		H: r8(gbRegisterH), L: r8(gbRegisterL),
+		SP: c.readRegister(gbRegisterSP),
+		PC: c.readRegister(gbRegisterPC),
+	}
+}
+
+// Equal returns true if every register matches the other snapshot's.
+func (s CPUSnapshot) Equal(other CPUSnapshot) bool {
+	return s == other
+}
+
+// Diff returns a description of the registers that differ from the other
+// snapshot, like "B=0x12/0x34 PC=0x0100/0x0102", or an empty string if they
+// all match.
+func (s CPUSnapshot) Diff(other CPUSnapshot) string {
+	var diffs []string
+	diff8 := func(name string, a, b uint8) {
+		if a != b {
+			diffs = append(diffs, fmt.Sprintf("%s=0x%02X/0x%02X", name, a, b))
+		}
+	}
+	diff16 := func(name string, a, b uint16) {
+		if a != b {
+			diffs = append(diffs, fmt.Sprintf("%s=0x%04X/0x%04X", name, a, b))
+		}
+	}
+
+	diff8("A", s.A, other.A)
+	diff8("F", s.F, other.F)
+	diff8("B", s.B, other.B)
+	diff8("C", s.C, other.C)
+	diff8("D", s.D, other.D)
+	diff8("E", s.E, other.E)
+	diff8("H", s.H, other.H)
+	diff8("L", s.L, other.L)
+	diff16("SP", s.SP, other.SP)
+	diff16("PC", s.PC, other.PC)
+
+	return strings.Join(diffs, " ")
+}
+
 func (c *gbCPU) load(r ram) (*gbOpcode, error) {
+	// Opcodes are at most 3 bytes, so fetch them in a single read where
+	// reading has no side effects and the end of memory allows. Otherwise
+	// fetch the first byte, and then only the bytes the opcode needs, so
+	// that watches and statistics only see the bytes the CPU really reads.
 	addr := uint32(c.readRegister(gbRegisterPC))
-	op, err := r.read(addr)
+	n := uint32(1)
+	if isFlatMemory(r, addr, addr+2) {
+		n = 3
+		if addr+n > gbMaxAddress && addr < gbMaxAddress {
+			n = gbMaxAddress - addr
+		}
+	}
+
+	ops, err := r.ReadSlice(addr, n)
 	if err != nil {
 		return nil, err
 	}
 
-	ops := []uint8{op}
-	opcode, n, err := decode(ops)
-	if err != nil && err != gbErrWrongOpcodeSize {
-		return nil, err
+	// After the HALT bug, PC fails to advance past the first byte of the
+	// opcode, so it's read again as the next byte.
+	next := addr + 1
+	if c.haltBug {
+		next = addr
+		if len(ops) > 1 {
+			ops = append([]uint8{ops[0]}, ops[:len(ops)-1]...)
+		}
 	}
-	if err == nil {
-		return opcode, nil
+
+	opcode, err := decode(ops[:1])
+	var sizeErr *WrongOpcodeSizeError
+	if !errors.As(err, &sizeErr) {
+		return opcode, err
 	}
-	if n < 0 {
+	if sizeErr.Missing < 0 {
 		panic(gbErrIncompatibleOpcodeSize) // should never get here
 	}
-
-	// Opcode requires more data.
-	opsn, err := readN(r, addr+1, uint32(n))
-	if err != nil {
-		return nil, err
+	if len(ops) == 1 && next+uint32(sizeErr.Missing) <= gbMaxAddress {
+		rest, err := r.ReadSlice(next, uint32(sizeErr.Missing))
+		if err != nil {
+			return nil, err
+		}
+		ops = append([]uint8{ops[0]}, rest...)
+	}
+	if 1+sizeErr.Missing > len(ops) {
+		return nil, gbErrOutOfBounds
 	}
 
-	opcode, n, err = decode(append(ops, opsn...))
-	if n != 0 {
+	opcode, err = decode(ops[:1+sizeErr.Missing])
+	if errors.As(err, &sizeErr) {
 		panic(gbErrIncompatibleOpcodeSize)
 	}
 
 	return opcode, err
 }
 
-func (c *gbCPU) execute(r ram, op *gbOpcode) error {
-	switch op.tipe {
-	case gbOpcodeLDRRp:
-		to := decodeRegisterType(op.first)
-		from := decodeRegisterType(op.second)
-		pokeRegisterIntoRegister(c, from, to)
-		return nil
-
-	case gbOpcodeLDRHl:
-		to := decodeRegisterType(op.first)
-		addr := uint32(c.readRegister(gbRegisterHL))
-		return pokeRAMIntoRegister(c, r, to, addr, true)
-
-	case gbOpcodeLDHlR:
-		from := decodeRegisterType(op.second)
-		addr := uint32(c.readRegister(gbRegisterHL))
-		return pokeRegisterIntoRAM(c, r, from, addr, true)
-
-	case gbOpcodeLDRN:
-		to := decodeRegisterType(op.first)
-		c.pokeRegister(uint16(op.data[0]), to)
-		return nil
-
-	case gbOpcodeLDHlN:
-		addr := uint32(c.readRegister(gbRegisterHL))
-		return r.poke(addr, op.data[0])
+// isFlatMemory returns true if reading the addresses from start to end
+// inclusive has no side effects, so that reading more than is needed is
+// harmless.
+func isFlatMemory(r ram, start, end uint32) bool {
+	switch m := r.(type) {
+	case *gbRAM:
+		return true
 
-	default:
-		return gbErrUnknownOpcode
+	case *gbMemoryBus:
+		return m.isFlat(start, end)
 	}
 
-	// TODO(guy): Update PC register where necessary.
+	return false
 }
 
-// runInstructionCycle performs a full fetch, decode and execute cycle.
-func runInstructionCycle(c cpu, r ram) error {
-	opcode, err := c.load(r)
-	if err != nil {
-		return err
+func (c *gbCPU) execute(r ram, op *gbOpcode) (int, error) {
+	// Each of the opcode's bytes took a memory access to fetch, except for
+	// STOP's padding byte, which is skipped without one.
+	fetched := op.size()
+	if op.tipe == gbOpcodeSTOP {
+		fetched = 1
 	}
+	c.totalCycles += 4 * uint64(fetched)
 
-	return c.execute(r, opcode)
+	c.bus = gbCPUBus{ram: r, c: c}
+	return c.executeOpcode(&c.bus, op)
+}
+
+// idle counts a machine cycle the CPU spends on an internal step, without
+// accessing memory.
+func (c *gbCPU) idle() {
+	c.totalCycles += 4
 }
 
-func pokeRegisterIntoRAM(c cpu, r ram, t gbRegisterType,
-	addr uint32, only8Bit bool) error {
+// gbCPUBus is the memory an opcode executes against, which counts the 4
+// cycles each read or write takes towards the CPU's total.
+type gbCPUBus struct {
+	ram
+	c *gbCPU
+}
 
-	val := c.readRegister(t)
-	first := uint8(val & 0xFF)
-	second := uint8(val >> 8)
+func (b *gbCPUBus) read(addr uint32) (uint8, error) {
+	b.c.totalCycles += 4
+	return b.ram.read(addr)
+}
 
-	err := r.poke(addr, first)
-	if err != nil {
-		return err
+func (b *gbCPUBus) poke(addr uint32, val uint8) error {
+	b.c.totalCycles += 4
+	return b.ram.poke(addr, val)
+}
+
+func (b *gbCPUBus) ReadSlice(addr uint32, n uint32) ([]uint8, error) {
+	b.c.totalCycles += 4 * uint64(n)
+	return b.ram.ReadSlice(addr, n)
+}
+
+func (c *gbCPU) executeOpcode(r *gbCPUBus, op *gbOpcode) (int, error) {
+	pc := c.readRegister(gbRegisterPC)
+	if fn, ok := c.breakpoints[pc]; ok {
+		fn(c)
 	}
 
-	if !only8Bit {
-		err = r.poke(addr+1, second)
+	// EI only takes effect once the opcode after it has started.
+	if c.pendingIME {
+		c.ime = true
+		c.pendingIME = false
 	}
 
-	return err
-}
+	// The PC register points past the opcode while it executes. After the
+	// HALT bug, it's one byte short.
+	next := pc + uint16(op.size())
+	if c.haltBug {
+		next--
+		c.haltBug = false
+	}
+	c.pokeRegister(next, gbRegisterPC)
 
-func pokeRAMIntoRegister(c cpu, r ram, t gbRegisterType,
-	addr uint32, only8Bit bool) error {
+	switch op.tipe {
+	case gbOpcodeNOP:
+		return op.cycles, nil
+
+	case gbOpcodeSTOP:
+		// TODO(guy): Stop the CPU until a button is pressed when there's no
+		// speed switch to perform.
+		if c.cgb && c.speedArmed {
+			c.doubleSpeed = !c.doubleSpeed
+			c.speedArmed = false
+		}
+		return op.cycles, nil
+
+	case gbOpcodeDI:
+		c.ime = false
+		return op.cycles, nil
+
+	case gbOpcodeEI:
+		c.pendingIME = true
+		return op.cycles, nil
+
+	case gbOpcodeHALT:
+		// The interrupt lines are wired to the CPU, so checking them doesn't
+		// take a memory access.
+		pending, err := pendingInterrupts(r.ram)
+		if err != nil {
+			return 0, err
+		}
+
+		// With interrupts disabled and one already pending, the CPU carries
+		// on instead of halting, but fails to advance PC (the HALT bug).
+		if !c.ime && pending != 0 {
+			c.haltBug = true
+			return op.cycles, nil
+		}
+		c.halted = true
+		return op.cycles, nil
+
+	case gbOpcodeLDRRp, gbOpcodeLDRHl, gbOpcodeLDHlR:
+		val, err := readOperand(c, r, decodeRegisterType(op.second))
+		if err != nil {
+			return 0, err
+		}
+		return op.cycles, pokeOperand(c, r, decodeRegisterType(op.first), val)
+
+	case gbOpcodeLDRN, gbOpcodeLDHlN:
+		return op.cycles, pokeOperand(c, r, decodeRegisterType(op.first), op.data[0])
+
+	case gbOpcodeJRN:
+		jumpRelative(c, op.data[0])
+		c.idle()
+		return op.cycles, nil
+
+	case gbOpcodeJRCcN:
+		if !conditionHolds(c, op.first) {
+			return op.cycles, nil
+		}
+		jumpRelative(c, op.data[0])
+		c.idle()
+		return gbOpcodeCycles[op.tipe][0], nil
+
+	case gbOpcodeJPNn:
+		c.pokeRegister(uint16(op.data[1])<<8+uint16(op.data[0]), gbRegisterPC)
+		c.idle()
+		return op.cycles, nil
+
+	case gbOpcodeCALL:
+		c.idle() // decrementing SP
+		if err := c.pushStack(r, c.readRegister(gbRegisterPC)); err != nil {
+			return 0, err
+		}
+		c.pokeRegister(uint16(op.data[1])<<8+uint16(op.data[0]), gbRegisterPC)
+		return op.cycles, nil
+
+	case gbOpcodePUSH:
+		c.idle() // decrementing SP
+		return op.cycles, c.pushStack(r, c.readRegister(decodeStackRegisterType(op.first>>1)))
 
-	vals, err := readN(r, addr, 1)
-	if !only8Bit {
-		vals, err = readN(r, addr, 2)
+	default:
+		return 0, gbErrUnknownOpcode
 	}
+}
+
+// runInstructionCycle performs a full fetch, decode and execute cycle,
+// returning the number of cycles the instruction took in units of 4 quartz
+// cycles.
+func runInstructionCycle(c cpu, r ram) (int, error) {
+	opcode, err := c.load(r)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	val := uint16(vals[0])
-	if !only8Bit {
-		// TODO(guy): Check endianness here against spec
-		val = uint16(vals[0]<<8) + uint16(vals[1])
+	return c.execute(r, opcode)
+}
+
+// readOperand returns the 8-bit operand encoded as the given register type,
+// which is either a register or the byte at (HL).
+func readOperand(c cpu, r ram, rt gbRegisterType) (uint8, error) {
+	if rt == gbRegisterHLIndirect {
+		return r.read(uint32(c.readRegister(gbRegisterHL)))
+	}
+
+	return uint8(c.readRegister(rt)), nil
+}
+
+// pokeOperand assigns the given value to the 8-bit operand encoded as the
+// given register type, which is either a register or the byte at (HL).
+func pokeOperand(c cpu, r ram, rt gbRegisterType, val uint8) error {
+	if rt == gbRegisterHLIndirect {
+		return r.poke(uint32(c.readRegister(gbRegisterHL)), val)
 	}
-	gbErrUnknownOpcode = errors.New("gbCPU: unknown opcode")
 
-	c.pokeRegister(val, t)
+	c.pokeRegister(uint16(val), rt)
 	return nil
 }
 
-func pokeRegisterIntoRegister(c cpu, from, to gbRegisterType) {
-	// TODO(guy): Check endianness here.
-	c.pokeRegister(c.readRegister(from), to)
+// jumpRelative adds the given offset to the PC register. The offset is a
+// two's-complement signed byte.
+func jumpRelative(c cpu, offset uint8) {
+	pc := c.readRegister(gbRegisterPC)
+	c.pokeRegister(uint16(int32(pc)+int32(int8(offset))), gbRegisterPC)
 }
@@ -2,6 +2,7 @@ package gb
 
 import (
 	"fmt"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -37,14 +38,15 @@ func Test8BitLD_R_R(t *testing.T) {
 			t1 := decodeRegisterType(r1)
 			t2 := decodeRegisterType(r2)
 			opcode := (opcodeHeader << 6) + (r1 << 3) + r2
-			c, r := prepareForOpcodes(t, []uint8{opcode})
+			g := prepareGameboy(t, []uint8{opcode})
+			c := g.cpu
 
 			// Write something to the source and dest registers for test.
 			c.pokeRegister(v1, t1)
 			c.pokeRegister(v2, t2)
 
 			// Run a full instruction cycle on the CPU.
-			assert.NoError(t, runInstructionCycle(c, r))
+			assert.NoError(t, g.RunN(1))
 			assert.Equal(t, v2, c.readRegister(t1))
 			assert.Equal(t, v2, c.readRegister(t2))
 		}
@@ -75,14 +77,18 @@ func Test8BitLD_HL_R(t *testing.T) {
 		return func(t *testing.T) {
 			rt := decodeRegisterType(r)
 			opcode := (opcodeHeader << 6) + (opcodeHLReg << 3) + r
-			c, r := prepareForOpcodes(t, []uint8{opcode})
+			c := newGBCPU()
+			m := newMockRAM(c)
+			assert.NoError(t, pokeN(m, 0x100, []uint8{opcode}))
+			c.pokeRegister(0x100, gbRegisterPC)
 
 			// Write something to the source register.
 			c.pokeRegister(v1, rt)
 
 			// Write something to memory and set (HL) to its address.
-			assert.NoError(t, r.poke(addr, v2))
+			assert.NoError(t, m.poke(addr, v2))
 			c.pokeRegister(uint16(addr), gbRegisterHL)
+			m.reset()
 
 			// We expect the value in memory afterwards to be equal to R, but
 			// if it's the H or L register we need to be careful because we
@@ -95,13 +101,11 @@ func Test8BitLD_HL_R(t *testing.T) {
 				expectedMem = uint8(c.readRegister(gbRegisterL))
 			}
 
-			// Run a full instruction cycle on the CPU.
-			assert.NoError(t, runInstructionCycle(c, r))
-			mem, err := r.read(addr)
-			if !assert.NoError(t, err) {
-				return
-			}
-			assert.Equal(t, expectedMem, mem)
+			// Run a full instruction cycle on the CPU, which should make a
+			// single write to (HL) in the machine cycle after the fetch.
+			_, err := runInstructionCycle(c, m)
+			assert.NoError(t, err)
+			assert.Equal(t, []mockAccess{{addr, expectedMem, 8}}, m.pokes)
 		}
 	}
 
@@ -138,7 +142,8 @@ func Test8BitLD_R_HL(t *testing.T) {
 			c.pokeRegister(uint16(addr), gbRegisterHL)
 
 			// Run a full instruction cycle on the CPU.
-			assert.NoError(t, runInstructionCycle(c, r))
+			_, err := runInstructionCycle(c, r)
+			assert.NoError(t, err)
 			assert.Equal(t, uint16(v2), c.readRegister(rt))
 		}
 	}
@@ -171,7 +176,8 @@ func Test8BitLD_R_N(t *testing.T) {
 			c.pokeRegister(v, rt)
 
 			// Run a full instruction cycle on the CPU.
-			assert.NoError(t, runInstructionCycle(c, r))
+			_, err := runInstructionCycle(c, r)
+			assert.NoError(t, err)
 			assert.Equal(t, uint16(n), c.readRegister(rt))
 		}
 	}
@@ -202,10 +208,507 @@ func Test8BitLD_Hl_N(t *testing.T) {
 	c.pokeRegister(uint16(addr), gbRegisterHL)
 
 	// Run a full instruction cycle on the CPU.
-	assert.NoError(t, runInstructionCycle(c, r))
+	_, err := runInstructionCycle(c, r)
+	assert.NoError(t, err)
 	mem, err := r.read(addr)
 	if !assert.NoError(t, err) {
 		return
 	}
 	assert.Equal(t, n, mem)
 }
+
+// TestJR_N tests the [JR n] opcode.
+func TestJR_N(t *testing.T) {
+	testFn := func(offset uint8, pc uint16) func(*testing.T) {
+		return func(t *testing.T) {
+			c, r := prepareForOpcodes(t, []uint8{0x18, offset})
+			expected := NewCPUSnapshot(c)
+			expected.PC = pc
+
+			_, err := runInstructionCycle(c, r)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, NewCPUSnapshot(c))
+		}
+	}
+
+	// The offset is signed and relative to the end of the instruction.
+	t.Run("forward", testFn(0x05, 0x107))
+	t.Run("forward max", testFn(0x7F, 0x181))
+	t.Run("backward", testFn(0xFE, 0x100))
+	t.Run("backward max", testFn(0x80, 0x82))
+}
+
+// TestBreakpoint tests that breakpoint hooks are called before the
+// instruction at their address executes.
+func TestBreakpoint(t *testing.T) {
+	g := prepareGameboy(t, []uint8{
+		0x00,       // 0x100: NOP
+		0x18, 0x01, // 0x101: JR 1
+		0x00,       // 0x103: NOP
+		0x18, 0xFE, // 0x104: JR -2
+	})
+	c := g.cpu.(*gbCPU)
+
+	var jrCalls, loopCalls int
+	c.SetBreakpoint(0x101, func(c *gbCPU) {
+		assert.Equal(t, uint16(0x101), c.readRegister(gbRegisterPC))
+		jrCalls++
+	})
+	c.SetBreakpoint(0x104, func(c *gbCPU) {
+		loopCalls++
+	})
+
+	runFrames(t, g, 1)
+	assert.Equal(t, 1, jrCalls)
+	assert.True(t, loopCalls > 1)
+
+	c.SetBreakpoint(0x104, nil)
+	runFrames(t, g, 1)
+	assert.Equal(t, 1, jrCalls)
+}
+
+// TestRegisterTypeString tests the names of the register types.
+func TestRegisterTypeString(t *testing.T) {
+	assert.Equal(t, "A", gbRegisterA.String())
+	assert.Equal(t, "BC", gbRegisterBC.String())
+	assert.Equal(t, "PC", gbRegisterPC.String())
+	assert.Equal(t, "UNKNOWN", gbRegisterUnknown.String())
+	assert.Equal(t, "UNKNOWN", gbRegisterType(42).String())
+
+	for rt := gbRegisterA; rt <= gbRegisterHL; rt++ {
+		assert.NotEqual(t, "UNKNOWN", rt.String())
+	}
+}
+
+// TestCPUSnapshotDiff tests comparing CPU snapshots.
+func TestCPUSnapshotDiff(t *testing.T) {
+	c := newGBCPU()
+	c.pokeRegister(0x1234, gbRegisterBC)
+	c.pokeRegister(0x0100, gbRegisterPC)
+	a := NewCPUSnapshot(c)
+	assert.Equal(t, CPUSnapshot{B: 0x12, C: 0x34, PC: 0x0100}, a)
+
+	c.pokeRegister(0x1235, gbRegisterBC)
+	c.pokeRegister(0x0102, gbRegisterPC)
+	b := NewCPUSnapshot(c)
+
+	assert.True(t, a.Equal(a))
+	assert.False(t, a.Equal(b))
+	assert.Equal(t, "", a.Diff(a))
+	assert.Equal(t, "C=0x34/0x35 PC=0x0100/0x0102", a.Diff(b))
+}
+
+// TestRegisterHLIndirect tests that the (HL) encoding decodes to a sentinel
+// that opcodes handle themselves, rather than passing it to the registers.
+func TestRegisterHLIndirect(t *testing.T) {
+	assert.Equal(t, gbRegisterHLIndirect, decodeRegisterType(gbOpcodePart110))
+	assert.Equal(t, "(HL)", gbRegisterHLIndirect.String())
+
+	c := newGBCPU()
+	assert.Panics(t, func() { c.readRegister(gbRegisterHLIndirect) })
+	assert.Panics(t, func() { c.pokeRegister(0, gbRegisterHLIndirect) })
+
+	// Every LD opcode involving (HL) runs without touching the sentinel.
+	for op := 0x40; op < 0x80; op++ {
+		if op == 0x76 || (op&0x7 != 0x6 && op&0x38 != 0x30) {
+			continue
+		}
+		c, r := prepareForOpcodes(t, []uint8{uint8(op), 0x42})
+		c.pokeRegister(0xC000, gbRegisterHL)
+		assert.NotPanics(t, func() {
+			_, err := runInstructionCycle(c, r)
+			assert.NoError(t, err)
+		}, "opcode 0x%02X", op)
+	}
+	c, r := prepareForOpcodes(t, []uint8{0x36, 0x42})
+	c.pokeRegister(0xC000, gbRegisterHL)
+	assert.NotPanics(t, func() {
+		_, err := runInstructionCycle(c, r)
+		assert.NoError(t, err)
+	})
+}
+
+// TestDumpRegisters tests the register dump format.
+func TestDumpRegisters(t *testing.T) {
+	c := newGBCPU()
+	c.pokeRegister(0x12A0, gbRegisterAF) // Z=1 N=0 H=1 C=0
+	c.pokeRegister(0x5678, gbRegisterBC)
+	c.pokeRegister(0xABCD, gbRegisterDE)
+	c.pokeRegister(0xEF01, gbRegisterHL)
+	c.pokeRegister(0xFFFE, gbRegisterSP)
+	c.pokeRegister(0x0100, gbRegisterPC)
+
+	assert.Equal(t, "AF=0x12A0 BC=0x5678 DE=0xABCD HL=0xEF01\n"+
+		"SP=0xFFFE PC=0x0100\n"+
+		"Z=1 N=0 H=1 C=0", c.DumpRegisters())
+}
+
+// TestJP_Nn tests the [JP nn] opcode.
+func TestJP_Nn(t *testing.T) {
+	c, r := prepareForOpcodes(t, []uint8{0xC3, 0x34, 0x12})
+	expected := NewCPUSnapshot(c)
+	expected.PC = 0x1234
+
+	_, err := runInstructionCycle(c, r)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, NewCPUSnapshot(c))
+}
+
+// TestPUSH tests the [PUSH qq] opcodes, which write the high byte first.
+func TestPUSH(t *testing.T) {
+	testFn := func(opcode uint8, reg gbRegisterType, val uint16) func(*testing.T) {
+		return func(t *testing.T) {
+			c := newGBCPU()
+			r := newMockRAM(c)
+			assert.NoError(t, pokeN(r, 0x100, []uint8{opcode}))
+			c.pokeRegister(0x100, gbRegisterPC)
+			c.pokeRegister(0xFFFE, gbRegisterSP)
+			c.pokeRegister(val, reg)
+			r.reset()
+
+			_, err := runInstructionCycle(c, r)
+			assert.NoError(t, err)
+			assert.Equal(t, uint16(0xFFFC), c.readRegister(gbRegisterSP))
+			assert.Equal(t, uint8(val), r.mem[0xFFFC])
+			assert.Equal(t, uint8(val>>8), r.mem[0xFFFD])
+			assert.Equal(t, []mockAccess{
+				{0xFFFD, uint8(val >> 8), 12},
+				{0xFFFC, uint8(val), 16},
+			}, r.pokes)
+		}
+	}
+
+	t.Run("BC", testFn(0xC5, gbRegisterBC, 0xABCD))
+	t.Run("DE", testFn(0xD5, gbRegisterDE, 0xABCD))
+	t.Run("HL", testFn(0xE5, gbRegisterHL, 0xABCD))
+	t.Run("AF", testFn(0xF5, gbRegisterAF, 0xABC0))
+}
+
+// TestStack tests that values pushed onto the stack pop back off it.
+func TestStack(t *testing.T) {
+	c := newGBCPU()
+	r := newGBRAM()
+	c.pokeRegister(0xFFFE, gbRegisterSP)
+
+	assert.NoError(t, c.pushStack(r, 0xABCD))
+	assert.Equal(t, uint16(0xFFFC), c.readRegister(gbRegisterSP))
+	assert.NoError(t, c.pushStack(r, 0x1234))
+	assert.Equal(t, uint16(0xFFFA), c.readRegister(gbRegisterSP))
+
+	val, err := c.popStack(r)
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(0x1234), val)
+	val, err = c.popStack(r)
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(0xABCD), val)
+	assert.Equal(t, uint16(0xFFFE), c.readRegister(gbRegisterSP))
+}
+
+// TestCALL tests the [CALL nn] opcode.
+func TestCALL(t *testing.T) {
+	c, r := prepareForOpcodes(t, []uint8{0xCD, 0x34, 0x12})
+	c.pokeRegister(0xFFFE, gbRegisterSP)
+
+	_, err := runInstructionCycle(c, r)
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(0x1234), c.readRegister(gbRegisterPC))
+	assert.Equal(t, uint16(0xFFFC), c.readRegister(gbRegisterSP))
+	assert.Equal(t, uint8(0x03), r.mem[0xFFFC])
+	assert.Equal(t, uint8(0x01), r.mem[0xFFFD])
+}
+
+// TestJR_Cc_N tests the [JR cc,n] opcodes.
+func TestJR_Cc_N(t *testing.T) {
+	testFn := func(cc uint8, flags uint8, taken bool) func(*testing.T) {
+		return func(t *testing.T) {
+			opcode := (gbOpcodeHeader00 << 6) + ((0x4 | cc) << 3)
+			c, r := prepareForOpcodes(t, []uint8{opcode, 0x10})
+			c.pokeRegister(uint16(flags), gbRegisterF)
+
+			expected := uint16(0x102)
+			if taken {
+				expected += 0x10
+			}
+
+			cycles, err := runInstructionCycle(c, r)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, c.readRegister(gbRegisterPC))
+
+			expectedCycles := gbOpcodeCycles[gbOpcodeJRCcN][1]
+			if taken {
+				expectedCycles = gbOpcodeCycles[gbOpcodeJRCcN][0]
+			}
+			assert.Equal(t, expectedCycles, cycles)
+		}
+	}
+
+	t.Run("NZ taken", testFn(0, 0, true))
+	t.Run("NZ not taken", testFn(0, gbFlagZero, false))
+	t.Run("Z taken", testFn(1, gbFlagZero, true))
+	t.Run("Z not taken", testFn(1, 0, false))
+	t.Run("NC taken", testFn(2, 0, true))
+	t.Run("NC not taken", testFn(2, gbFlagCarry, false))
+	t.Run("C taken", testFn(3, gbFlagCarry, true))
+	t.Run("C not taken", testFn(3, 0, false))
+}
+
+// TestHALT tests that HALT waits for an interrupt to be pending.
+func TestHALT(t *testing.T) {
+	c, r := prepareForOpcodes(t, []uint8{0x76, 0x00})
+	assert.NoError(t, r.poke(gbAddrIE, 0x01))
+
+	_, err := runInstructionCycle(c, r)
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(0x101), c.readRegister(gbRegisterPC))
+
+	halted, err := c.isHalted(r)
+	assert.NoError(t, err)
+	assert.True(t, halted)
+
+	// Requesting the enabled interrupt wakes the CPU up.
+	assert.NoError(t, r.poke(gbAddrIF, 0x01))
+	halted, err = c.isHalted(r)
+	assert.NoError(t, err)
+	assert.False(t, halted)
+}
+
+// TestHALTBug tests that HALT with interrupts disabled and one pending makes
+// the CPU read the byte after HALT twice.
+func TestHALTBug(t *testing.T) {
+	c, r := prepareForOpcodes(t, []uint8{
+		0x76,       // HALT
+		0x26, 0x2E, // LD H, 0x2E
+		0x42, // LD B, D
+	})
+	assert.NoError(t, r.poke(gbAddrIE, 0x01))
+	assert.NoError(t, r.poke(gbAddrIF, 0x01))
+
+	_, err := runInstructionCycle(c, r)
+	assert.NoError(t, err)
+	halted, err := c.isHalted(r)
+	assert.NoError(t, err)
+	assert.False(t, halted)
+
+	// The LD H, n opcode is read as its own operand, leaving PC on 0x2E,
+	// which is read as LD L, 0x42.
+	expected := NewCPUSnapshot(c)
+	expected.H, expected.PC = 0x26, 0x102
+	_, err = runInstructionCycle(c, r)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, NewCPUSnapshot(c))
+
+	expected.L, expected.PC = 0x42, 0x104
+	_, err = runInstructionCycle(c, r)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, NewCPUSnapshot(c))
+}
+
+// TestEI tests that EI only enables interrupts after the next opcode.
+func TestEI(t *testing.T) {
+	c, r := prepareForOpcodes(t, []uint8{0xFB, 0x00})
+	_, err := runInstructionCycle(c, r)
+	assert.NoError(t, err)
+	assert.False(t, c.ime)
+
+	_, err = runInstructionCycle(c, r)
+	assert.NoError(t, err)
+	assert.True(t, c.ime)
+
+	// A DI straight after EI cancels it.
+	c, r = prepareForOpcodes(t, []uint8{0xFB, 0xF3, 0x00})
+	for i := 0; i < 3; i++ {
+		_, err = runInstructionCycle(c, r)
+		assert.NoError(t, err)
+		assert.False(t, c.ime)
+	}
+}
+
+// TestReadIntoRegisterConcurrent tests that independent CPUs can execute
+// loads from memory concurrently. Run with -race to check for package-level
+// data races.
+func TestReadIntoRegisterConcurrent(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func(v uint8) {
+			defer wg.Done()
+
+			c, r := prepareForOpcodes(t, []uint8{
+				0x46,       // 0x100: LD B, (HL)
+				0x48,       // 0x101: LD C, B
+				0x18, 0xFC, // 0x102: JR -4
+			})
+			c.pokeRegister(0x200, gbRegisterHL)
+			assert.NoError(t, r.poke(0x200, v))
+			for j := 0; j < 300; j++ {
+				if _, err := runInstructionCycle(c, r); !assert.NoError(t, err) {
+					return
+				}
+			}
+			assert.Equal(t, uint16(0x100), c.readRegister(gbRegisterPC))
+			assert.Equal(t, uint16(v), c.readRegister(gbRegisterB))
+			assert.Equal(t, uint16(v), c.readRegister(gbRegisterC))
+		}(uint8(0x42 + i))
+	}
+
+	wg.Wait()
+}
+
+// BenchmarkLoad measures fetching and decoding one and three byte opcodes.
+func BenchmarkLoad(b *testing.B) {
+	benchFn := func(opcode []uint8) func(*testing.B) {
+		return func(b *testing.B) {
+			c, r := newGBCPU(), newGBRAM()
+			pokeN(r, 0x100, opcode)
+			c.pokeRegister(0x100, gbRegisterPC)
+
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := c.load(r); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	}
+
+	b.Run("LD B,C", benchFn([]uint8{0x41}))
+	b.Run("JP nn", benchFn([]uint8{0xC3, 0x34, 0x12}))
+}
+
+// BenchmarkLoadBus measures fetching and decoding opcodes through the bus.
+func BenchmarkLoadBus(b *testing.B) {
+	benchFn := func(opcode []uint8) func(*testing.B) {
+		return func(b *testing.B) {
+			c, r := newGBCPU(), newGBMemoryBus()
+			pokeN(r, 0x100, opcode)
+			c.pokeRegister(0x100, gbRegisterPC)
+
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := c.load(r); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	}
+
+	b.Run("LD B,C", benchFn([]uint8{0x41}))
+	b.Run("JP nn", benchFn([]uint8{0xC3, 0x34, 0x12}))
+}
+
+// BenchmarkRunInstructionCycle measures fetching, decoding and executing
+// opcodes from memory filled with a repeating pattern of them.
+func BenchmarkRunInstructionCycle(b *testing.B) {
+	benchFn := func(pattern []uint8) func(*testing.B) {
+		return func(b *testing.B) {
+			c, r := newGBCPU(), newGBRAM()
+			for addr := 0; addr < gbMaxAddress; addr += len(pattern) {
+				pokeN(r, uint32(addr), pattern)
+			}
+			c.pokeRegister(0x100, gbRegisterPC)
+
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := runInstructionCycle(c, r); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	}
+
+	b.Run("NOP", benchFn([]uint8{0x00}))
+	b.Run("LD R,R'", benchFn([]uint8{
+		0x41, // LD B,C
+		0x5A, // LD E,D
+		0x63, // LD H,E
+		0x7C, // LD A,H
+		0x47, // LD B,A
+		0x78, // LD A,B
+		0x6F, // LD L,A
+		0x4D, // LD C,L
+	}))
+}
+
+// TestTotalCycles tests counting the cycles spent executing opcodes, as each
+// memory access and internal step happens.
+func TestTotalCycles(t *testing.T) {
+	c, r := prepareForOpcodes(t, []uint8{
+		0x46,       // 0x100: LD B, (HL)
+		0x00,       // 0x101: NOP
+		0x20, 0x00, // 0x102: JR NZ, 0
+	})
+	c.pokeRegister(0x200, gbRegisterHL)
+
+	// LD B, (HL) takes one memory access for the fetch and one for the read.
+	for _, expected := range []uint64{8, 12, 24} {
+		_, err := runInstructionCycle(c, r)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, c.TotalCycles())
+	}
+
+	c2 := newGBCPU()
+	c2.restore(c.snapshot())
+	assert.Equal(t, uint64(24), c2.TotalCycles())
+
+	// Every opcode's accesses and steps add up to its machine cycles.
+	c, r = prepareForOpcodes(t, []uint8{
+		0x36, 0x42, // 0x100: LD (HL), 0x42
+		0x70,             // 0x102: LD (HL), B
+		0xC5,             // 0x103: PUSH BC
+		0xCD, 0x08, 0x01, // 0x104: CALL 0x0108
+		0x00,       // 0x107: NOP
+		0x18, 0x00, // 0x108: JR 0
+		0x28, 0x00, // 0x10A: JR Z, 0
+		0xC3, 0x00, 0x02, // 0x10C: JP 0x0200
+	})
+	c.pokeRegister(0x300, gbRegisterHL)
+	c.pokeRegister(0xD000, gbRegisterSP)
+	for c.readRegister(gbRegisterPC) != 0x200 {
+		before := c.TotalCycles()
+		cycles, err := runInstructionCycle(c, r)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, 4*uint64(cycles), c.TotalCycles()-before)
+	}
+}
+
+// TestTotalCyclesAllOpcodes tests that every decodable opcode's accesses and
+// steps add up to the machine cycles it returns.
+func TestTotalCyclesAllOpcodes(t *testing.T) {
+	var programs [][]uint8
+	for b := 0; b < 256; b++ {
+		programs = append(programs, []uint8{uint8(b), 0x00, 0x00})
+		programs = append(programs, []uint8{gbOpcodePrefixCB, uint8(b)})
+	}
+
+	for _, program := range programs {
+		if _, err := decodeAt(program, 0); err != nil {
+			continue
+		}
+
+		c, r := prepareForOpcodes(t, program)
+		c.pokeRegister(0xC000, gbRegisterHL)
+		c.pokeRegister(0xD000, gbRegisterSP)
+		cycles, err := runInstructionCycle(c, r)
+		if !assert.NoError(t, err, "opcode % X", program) {
+			continue
+		}
+		assert.Equal(t, 4*uint64(cycles), c.TotalCycles(), "opcode % X", program)
+	}
+}
+
+// TestLoadEndOfMemory tests fetching opcodes at the very end of memory.
+func TestLoadEndOfMemory(t *testing.T) {
+	c, r := prepareForOpcodes(t, nil)
+	c.pokeRegister(0xFFFF, gbRegisterPC)
+
+	op, err := c.load(r)
+	if assert.NoError(t, err) {
+		assert.Equal(t, gbOpcodeNOP, op.tipe)
+	}
+
+	assert.NoError(t, r.poke(0xFFFF, 0xC3)) // JP nn, missing its operand
+	_, err = c.load(r)
+	assert.Equal(t, gbErrOutOfBounds, err)
+}
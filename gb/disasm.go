@@ -0,0 +1,149 @@
+package gb
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	gbErrTruncatedOpcode = errors.New("gbDisassemble: data ends partway through an opcode")
+	gbErrROMRange        = errors.New("gbDisassemble: address range isn't within the ROM")
+)
+
+// DisassemblyLine is a single decoded instruction in a disassembly listing.
+type DisassemblyLine struct {
+	Address  uint16
+	Bytes    []byte
+	Mnemonic string
+	Operands string
+}
+
+// gbMnemonics maps each opcode type to its assembly mnemonic.
+var gbMnemonics = map[gbOpcodeType]string{
+	gbOpcodeLDRRp: "LD",
+	gbOpcodeLDRHl: "LD",
+	gbOpcodeLDHlR: "LD",
+	gbOpcodeLDRN:  "LD",
+	gbOpcodeLDHlN: "LD",
+	gbOpcodeNOP:   "NOP",
+	gbOpcodeSTOP:  "STOP",
+	gbOpcodeHALT:  "HALT",
+	gbOpcodeDI:    "DI",
+	gbOpcodeEI:    "EI",
+	gbOpcodeJRN:   "JR",
+	gbOpcodeJRCcN: "JR",
+	gbOpcodeJPNn:  "JP",
+	gbOpcodeCALL:  "CALL",
+	gbOpcodePUSH:  "PUSH",
+}
+
+// Disassemble decodes the given machine code into a listing of instructions,
+// where the first byte of data lives at startAddr. If it fails partway
+// through, the lines decoded so far are returned along with the error.
+func Disassemble(data []byte, startAddr uint16) ([]DisassemblyLine, error) {
+	var lines []DisassemblyLine
+	for pc := 0; pc < len(data); {
+		op, err := decodeAt(data, pc)
+		if err != nil {
+			return lines, err
+		}
+
+		addr := startAddr + uint16(pc)
+		lines = append(lines, DisassemblyLine{
+			Address:  addr,
+			Bytes:    append([]byte(nil), data[pc:pc+op.size()]...),
+			Mnemonic: gbMnemonics[op.tipe],
+			Operands: formatOperands(op, addr),
+		})
+		pc += op.size()
+	}
+
+	return lines, nil
+}
+
+// DisassembleROM renders a listing of the ROM from address start up to but not
+// including end, with one instruction per line. Bytes that don't decode, such
+// as data tables, are listed as .db directives.
+func DisassembleROM(rom []byte, start, end uint16) (string, error) {
+	if start > end || int(end) > len(rom) {
+		return "", gbErrROMRange
+	}
+
+	var b strings.Builder
+	data := rom[:end]
+	for pc := int(start); pc < len(data); {
+		op, err := decodeAt(data, pc)
+		if err != nil {
+			fmt.Fprintf(&b, "%04X  %-8s  .db 0x%02X\n", pc, fmt.Sprintf("%02X", data[pc]), data[pc])
+			pc++
+			continue
+		}
+
+		var bytes []string
+		for _, v := range data[pc : pc+op.size()] {
+			bytes = append(bytes, fmt.Sprintf("%02X", v))
+		}
+
+		asm := gbMnemonics[op.tipe]
+		if operands := formatOperands(op, uint16(pc)); operands != "" {
+			asm += " " + operands
+		}
+
+		fmt.Fprintf(&b, "%04X  %-8s  %s\n", pc, strings.Join(bytes, " "), asm)
+		pc += op.size()
+	}
+
+	return b.String(), nil
+}
+
+// decodeAt decodes the opcode starting at the given offset into data, feeding
+// decode more bytes until it has enough.
+func decodeAt(data []byte, offset int) (*gbOpcode, error) {
+	n := 1
+	for {
+		if offset+n > len(data) {
+			return nil, gbErrTruncatedOpcode
+		}
+
+		op, err := decode(data[offset : offset+n])
+		var sizeErr *WrongOpcodeSizeError
+		if errors.As(err, &sizeErr) && sizeErr.Missing > 0 {
+			n += sizeErr.Missing
+			continue
+		}
+
+		return op, err
+	}
+}
+
+// formatOperands renders the operands of an opcode located at addr.
+func formatOperands(op *gbOpcode, addr uint16) string {
+	first := decodeRegisterType(op.first)
+	second := decodeRegisterType(op.second)
+
+	switch op.tipe {
+	case gbOpcodeLDRRp, gbOpcodeLDRHl, gbOpcodeLDHlR:
+		return fmt.Sprintf("%s, %s", first, second)
+
+	case gbOpcodeLDRN, gbOpcodeLDHlN:
+		return fmt.Sprintf("%s, 0x%02X", first, op.data[0])
+
+	case gbOpcodeJRN:
+		next := int32(addr) + int32(op.size())
+		return fmt.Sprintf("0x%04X", uint16(next+int32(int8(op.data[0]))))
+
+	case gbOpcodeJRCcN:
+		next := int32(addr) + int32(op.size())
+		return fmt.Sprintf("%s, 0x%04X", gbConditionNames[op.first&0x3],
+			uint16(next+int32(int8(op.data[0]))))
+
+	case gbOpcodeJPNn, gbOpcodeCALL:
+		return fmt.Sprintf("0x%04X", uint16(op.data[1])<<8+uint16(op.data[0]))
+
+	case gbOpcodePUSH:
+		return decodeStackRegisterType(op.first >> 1).String()
+	}
+
+	return ""
+}
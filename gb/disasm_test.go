@@ -0,0 +1,78 @@
+package gb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDisassemble tests disassembling a short sequence of instructions.
+func TestDisassemble(t *testing.T) {
+	data := []byte{
+		0x41,       // LD B, C
+		0x53,       // LD D, E
+		0x00,       // NOP
+		0x36, 0x42, // LD (HL), 0x42
+		0x18, 0xFB, // JR -5
+	}
+
+	lines, err := Disassemble(data, 0x100)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, []DisassemblyLine{
+		{0x100, []byte{0x41}, "LD", "B, C"},
+		{0x101, []byte{0x53}, "LD", "D, E"},
+		{0x102, []byte{0x00}, "NOP", ""},
+		{0x103, []byte{0x36, 0x42}, "LD", "(HL), 0x42"},
+		{0x105, []byte{0x18, 0xFB}, "JR", "0x0102"},
+	}, lines)
+}
+
+// TestDisassembleErrors tests that invalid and truncated data is reported.
+func TestDisassembleErrors(t *testing.T) {
+	lines, err := Disassemble([]byte{0x00, 0x06}, 0x100)
+	assert.Equal(t, gbErrTruncatedOpcode, err)
+	assert.Len(t, lines, 1)
+
+	lines, err = Disassemble([]byte{0x00, 0xD3}, 0x100)
+	assert.Equal(t, gbErrInvalidOpcode, err)
+	assert.Len(t, lines, 1)
+}
+
+// TestDisassembleROM tests listing a ROM containing code and data.
+func TestDisassembleROM(t *testing.T) {
+	rom := []byte{
+		0x00,             // NOP
+		0xC3, 0x08, 0x00, // JP 0x0008
+		0xD3, 0xDD, // data
+		0x10, 0x00, // STOP
+		0x26, 0xC0, // LD H, 0xC0
+		0x70,       // LD (HL), B
+		0x7E,       // LD A, (HL)
+		0x20, 0xF2, // JR NZ, 0x0000
+		0x18, // truncated JR
+		0xFF, // past the end of the listing
+	}
+
+	listing, err := DisassembleROM(rom, 0x0000, 0x000F)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, ""+
+		"0000  00        NOP\n"+
+		"0001  C3 08 00  JP 0x0008\n"+
+		"0004  D3        .db 0xD3\n"+
+		"0005  DD        .db 0xDD\n"+
+		"0006  10 00     STOP\n"+
+		"0008  26 C0     LD H, 0xC0\n"+
+		"000A  70        LD (HL), B\n"+
+		"000B  7E        LD A, (HL)\n"+
+		"000C  20 F2     JR NZ, 0x0000\n"+
+		"000E  18        .db 0x18\n", listing)
+
+	_, err = DisassembleROM(rom, 0x0000, 0x0011)
+	assert.Equal(t, gbErrROMRange, err)
+}
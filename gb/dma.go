@@ -0,0 +1,93 @@
+package gb
+
+import "errors"
+
+const (
+	gbAddrDMA uint32 = 0xFF46 // OAM DMA source and start
+
+	gbOAMDMABytes  = gbOAMEntries * gbOAMEntrySize
+	gbOAMDMACycles = gbOAMDMABytes * 4 // quartz-cycles, one byte per 4
+)
+
+var (
+	gbErrDMAAddress = errors.New("gbOAMDMA: address isn't the DMA register")
+)
+
+// gbOAMDMA copies 160 bytes into OAM from the page written to the DMA
+// register, one byte every 4 quartz-cycles. While it runs, the DMA owns the
+// external and video buses, so the bus cuts the CPU off from everything below
+// the I/O registers.
+type gbOAMDMA struct {
+	bus *gbMemoryBus
+
+	reg    uint8 // high byte of the source address
+	cycles int   // quartz-cycles left in the transfer
+}
+
+// gbOAMDMAState is the serialisable internal state of a gbOAMDMA.
+type gbOAMDMAState struct {
+	Reg    uint8
+	Cycles int
+}
+
+func newGBOAMDMA(bus *gbMemoryBus) *gbOAMDMA {
+	return &gbOAMDMA{bus: bus}
+}
+
+func (d *gbOAMDMA) snapshot() gbOAMDMAState {
+	return gbOAMDMAState{d.reg, d.cycles}
+}
+
+func (d *gbOAMDMA) restore(s gbOAMDMAState) {
+	d.reg, d.cycles = s.Reg, s.Cycles
+	d.bus.dmaActive = d.cycles > 0
+}
+
+func (d *gbOAMDMA) poke(addr uint32, val uint8) error {
+	if addr != gbAddrDMA {
+		return gbErrDMAAddress
+	}
+
+	d.reg = val
+	d.cycles = gbOAMDMACycles
+	d.bus.dmaActive = true
+
+	return nil
+}
+
+func (d *gbOAMDMA) read(addr uint32) (uint8, error) {
+	if addr != gbAddrDMA {
+		return 0, gbErrDMAAddress
+	}
+
+	return d.reg, nil
+}
+
+func (d *gbOAMDMA) ReadSlice(addr, n uint32) ([]uint8, error) {
+	return readN(d, addr, n)
+}
+
+// tick moves the transfer forward by a single quartz-cycle, copying the next
+// byte at the end of every fourth.
+func (d *gbOAMDMA) tick() error {
+	if d.cycles == 0 {
+		return nil
+	}
+
+	d.cycles--
+	if d.cycles%4 == 0 {
+		i := uint32(gbOAMDMABytes - 1 - d.cycles/4)
+		val, err := d.bus.target(uint32(d.reg)<<8 + i).read(uint32(d.reg)<<8 + i)
+		if err != nil {
+			return err
+		}
+
+		// OAM is written around the bus, as the PPU may be locking it.
+		if err := d.bus.mem.poke(gbAddrOAM+i, val); err != nil {
+			return err
+		}
+	}
+	d.bus.dmaActive = d.cycles > 0
+
+	return nil
+}
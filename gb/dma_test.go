@@ -0,0 +1,44 @@
+package gb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestOAMDMA tests copying a page of memory into OAM, and that the CPU can
+// only reach the I/O registers and high RAM while it's running.
+func TestOAMDMA(t *testing.T) {
+	g := NewGameboy()
+	for i := uint32(0); i < gbOAMDMABytes; i++ {
+		assert.NoError(t, g.bus.poke(0xC100+i, uint8(i)))
+	}
+	assert.NoError(t, g.bus.poke(0x8000, 0x42))
+	assert.NoError(t, g.bus.poke(0xFF82, 0x24))
+
+	assert.NoError(t, g.bus.poke(gbAddrDMA, 0xC1))
+	for _, addr := range []uint32{0x8000, 0xC000, 0xC100} {
+		val, err := g.bus.read(addr)
+		assert.NoError(t, err)
+		assert.Equal(t, uint8(0xFF), val)
+	}
+	val, err := g.bus.read(0xFF82)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0x24), val)
+
+	// Writes outside high RAM are dropped.
+	assert.NoError(t, g.bus.poke(0xC000, 0x11))
+
+	for i := 0; i < gbOAMDMACycles; i++ {
+		assert.NoError(t, g.dma.tick())
+	}
+	assert.False(t, g.bus.dmaActive)
+	assert.Equal(t, g.bus.mem.mem[0xC100:0xC1A0], g.bus.mem.mem[gbAddrOAM:gbAddrOAMEnd+1])
+
+	val, err = g.bus.read(0x8000)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0x42), val)
+	val, err = g.bus.read(0xC000)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0x00), val)
+}
@@ -0,0 +1,66 @@
+package gb
+
+// GameboyState is a structured snapshot of the gameboy for debugging, test
+// assertions and crash reports. Unlike SaveState, it can't be loaded back.
+type GameboyState struct {
+	CPU CPUState
+
+	// IO holds the value of every I/O register, from 0xFF00 to 0xFF7F, and
+	// the interrupt enable register at 0xFFFF.
+	IO map[uint16]uint8
+
+	PPUMode int
+	LY      int
+
+	CycleCount uint64 // quartz-cycles since the gameboy started
+}
+
+// CPUState holds the values of the CPU's registers.
+type CPUState struct {
+	A, F, B, C, D, E, H, L uint8
+	SP, PC                 uint16
+}
+
+// DumpState returns a snapshot of the gameboy's current state.
+func (g *Gameboy) DumpState() (GameboyState, error) {
+	reg := func(rt gbRegisterType) uint8 {
+		return uint8(g.cpu.readRegister(rt))
+	}
+
+	s := GameboyState{
+		CPU: CPUState{
+			A: reg(gbRegisterA), F: reg(gbRegisterF),
+			B: reg(gbRegisterB), C: reg(gbRegisterC),
+			D: reg(gbRegisterD), E: reg(gbRegisterE),
+			H: reg(gbRegisterH), L: reg(gbRegisterL),
+			SP: g.cpu.readRegister(gbRegisterSP),
+			PC: g.cpu.readRegister(gbRegisterPC),
+		},
+		IO:         make(map[uint16]uint8, gbAddrIOEnd-gbAddrIO+2),
+		PPUMode:    g.ppu.Mode(),
+		LY:         g.ppu.Scanline(),
+		CycleCount: g.cycles,
+	}
+
+	// Go straight to the hardware so that dumping doesn't trip watchpoints.
+	dump := func(addr uint32) error {
+		val, err := g.bus.target(addr).read(addr)
+		if err != nil {
+			return err
+		}
+
+		s.IO[uint16(addr)] = val
+		return nil
+	}
+
+	for addr := gbAddrIO; addr <= gbAddrIOEnd; addr++ {
+		if err := dump(addr); err != nil {
+			return GameboyState{}, err
+		}
+	}
+	if err := dump(gbAddrIE); err != nil {
+		return GameboyState{}, err
+	}
+
+	return s, nil
+}
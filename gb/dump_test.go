@@ -0,0 +1,43 @@
+package gb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDumpState tests dumping the state of a running gameboy.
+func TestDumpState(t *testing.T) {
+	g := prepareGameboy(t, testProgram)
+	runInstructions(t, g, 5)
+
+	// Run until the CPU is about to fetch the JR.
+	for g.wait > 0 {
+		assert.NoError(t, g.Step())
+	}
+
+	s, err := g.DumpState()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, uint16(0x0108), s.CPU.PC)
+	assert.Equal(t, uint8(0xC0), s.CPU.H)
+	assert.Equal(t, uint8(0x42), s.CPU.B)
+	assert.Equal(t, uint8(0x42), s.CPU.C)
+
+	// LD H,n; LD L,n; LD (HL),n; LD B,(HL); LD C,B.
+	assert.Equal(t, uint64(4*(2+2+3+2+1)), s.CycleCount)
+	assert.Equal(t, gbPPUModeOAMScan, s.PPUMode)
+	assert.Equal(t, 0, s.LY)
+	assert.Equal(t, uint8(0), s.IO[0xFF44])
+	assert.Len(t, s.IO, 0x81)
+
+	for g.ppu.Scanline() != 10 {
+		assert.NoError(t, g.Step())
+	}
+	s, err = g.DumpState()
+	assert.NoError(t, err)
+	assert.Equal(t, 10, s.LY)
+	assert.Equal(t, uint8(10), s.IO[0xFF44])
+}
@@ -0,0 +1,74 @@
+package gb
+
+// OnVBlank registers a callback that fires whenever the PPU enters the
+// vertical blanking period, which happens once per frame.
+func (g *Gameboy) OnVBlank(fn func()) {
+	g.onVBlank = fn
+}
+
+// OnHBlank registers a callback that fires whenever the PPU enters the
+// horizontal blanking period at the end of a visible scanline.
+func (g *Gameboy) OnHBlank(fn func(line int)) {
+	g.onHBlank = fn
+}
+
+// OnScanline registers a callback that fires whenever the PPU finishes
+// transferring a visible scanline, with the line's 160 colour indices. The
+// pixels are a copy, so the callback can hold on to them.
+func (g *Gameboy) OnScanline(fn func(line int, pixels []uint8)) {
+	g.onScanline = fn
+}
+
+// SetVideoCallback registers a callback that fires with each completed frame
+// of colour indices as the PPU enters V-blank. The frame is a copy, so the
+// callback can hold on to it while the gameboy carries on.
+func (g *Gameboy) SetVideoCallback(fn func(framebuffer []uint8)) {
+	g.onVideo = fn
+}
+
+// OnBreakpoint registers a callback that fires whenever the CPU reaches an
+// address set with SetBreakpoint.
+func (g *Gameboy) OnBreakpoint(fn func(pc uint16)) {
+	g.onBreakpoint = fn
+}
+
+// SetBreakpoint makes the gameboy fire its OnBreakpoint callback just before
+// the CPU executes the instruction at the given address.
+func (g *Gameboy) SetBreakpoint(addr uint16) {
+	g.cpu.SetBreakpoint(addr, func(*gbCPU) {
+		if g.onBreakpoint != nil {
+			g.onBreakpoint(addr)
+		}
+	})
+}
+
+// ClearBreakpoint removes a breakpoint set with SetBreakpoint.
+func (g *Gameboy) ClearBreakpoint(addr uint16) {
+	g.cpu.SetBreakpoint(addr, nil)
+}
+
+// firePPUEvents fires the callbacks for the mode the PPU has just entered.
+func (g *Gameboy) firePPUEvents(mode int) {
+	switch mode {
+	case gbPPUModeVBlank:
+		if g.onVideo != nil && g.speed > 0 {
+			g.onVideo(append([]uint8(nil), g.ppu.Framebuffer()...))
+		}
+		if g.audio != nil && g.speed > 0 {
+			g.audio.flush()
+		}
+		if g.onVBlank != nil {
+			g.onVBlank()
+		}
+
+	case gbPPUModeHBlank:
+		line := g.ppu.Scanline()
+		if g.onScanline != nil {
+			pixels := g.ppu.Framebuffer()[line*gbScreenWidth : (line+1)*gbScreenWidth]
+			g.onScanline(line, append([]uint8(nil), pixels...))
+		}
+		if g.onHBlank != nil {
+			g.onHBlank(line)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package gb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestOnVBlank tests that the V-blank callback fires once per frame.
+func TestOnVBlank(t *testing.T) {
+	g := prepareGameboy(t, testProgram)
+
+	var calls int
+	g.OnVBlank(func() { calls++ })
+
+	runFrames(t, g, 1)
+	assert.Equal(t, 1, calls)
+	runFrames(t, g, 2)
+	assert.Equal(t, 3, calls)
+}
+
+// TestOnHBlank tests that the H-blank callback fires for each visible line.
+func TestOnHBlank(t *testing.T) {
+	g := prepareGameboy(t, testProgram)
+
+	var lines []int
+	g.OnHBlank(func(line int) { lines = append(lines, line) })
+
+	runFrames(t, g, 1)
+	if !assert.Len(t, lines, gbVisibleLines) {
+		return
+	}
+	for i, line := range lines {
+		assert.Equal(t, i, line)
+	}
+}
+
+// TestOnScanline tests that the scanline callback fires with a copy of the
+// pixels of each visible line.
+func TestOnScanline(t *testing.T) {
+	g := prepareBackground(t, DMG)
+	fillTile(t, g, 0x8010, 3)
+
+	var lines []int
+	var first []uint8
+	g.OnScanline(func(line int, pixels []uint8) {
+		lines = append(lines, line)
+		assert.Len(t, pixels, gbScreenWidth)
+		if line == 0 {
+			first = pixels
+		}
+	})
+
+	runFrames(t, g, 1)
+	if !assert.Len(t, lines, gbVisibleLines) {
+		return
+	}
+	for i, line := range lines {
+		assert.Equal(t, i, line)
+	}
+
+	// Changing the framebuffer leaves the pixels already delivered alone.
+	assert.Equal(t, uint8(3), first[0])
+	g.ppu.Framebuffer()[0] = 0
+	assert.Equal(t, uint8(3), first[0])
+}
+
+// TestSetVideoCallback tests that the video callback fires once per frame with
+// a copy of the framebuffer.
+func TestSetVideoCallback(t *testing.T) {
+	g := prepareBackground(t, DMG)
+	fillTile(t, g, 0x8010, 3)
+
+	var frames [][]uint8
+	g.SetVideoCallback(func(fb []uint8) { frames = append(frames, fb) })
+	runFrames(t, g, 3)
+
+	if !assert.Len(t, frames, 3) {
+		return
+	}
+	for _, fb := range frames {
+		assert.Len(t, fb, gbScreenWidth*gbScreenHeight)
+		assert.Equal(t, uint8(3), fb[0])
+	}
+
+	// Changing the framebuffer leaves the frames already delivered alone.
+	g.GetFramebuffer()[0] = 0
+	assert.Equal(t, uint8(3), frames[2][0])
+}
+
+// TestOnBreakpoint tests that the breakpoint callback fires with the address
+// of each breakpoint reached.
+func TestOnBreakpoint(t *testing.T) {
+	g := prepareGameboy(t, testProgram)
+
+	var pcs []uint16
+	g.OnBreakpoint(func(pc uint16) { pcs = append(pcs, pc) })
+	g.SetBreakpoint(0x102)
+	g.SetBreakpoint(0x108)
+
+	runInstructions(t, g, 6)
+	assert.Equal(t, []uint16{0x102, 0x108}, pcs)
+
+	g.ClearBreakpoint(0x108)
+	runInstructions(t, g, 4)
+	assert.Equal(t, []uint16{0x102, 0x108}, pcs)
+}
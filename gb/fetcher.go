@@ -0,0 +1,263 @@
+package gb
+
+// The steps of the background fetcher, each of which takes 2 dots.
+const (
+	gbFetchTile   = iota // read the tile index (and CGB attributes) from the map
+	gbFetchDataLo        // read the low bits of the tile's row
+	gbFetchDataHi        // read the high bits of the tile's row
+	gbFetchPush          // push the row into the FIFO once it's empty
+)
+
+const (
+	gbFetchStepDots = 2
+	gbFetchOBJDots  = 6 // dots the object fetcher pauses the background for
+)
+
+// bgFetcher fetches a line of the background from VRAM a tile at a time,
+// pushing each row of 8 pixels into the background FIFO. Pushing waits for
+// the FIFO to empty, so it holds up the next fetch until the pixels before
+// it have been drawn.
+type bgFetcher struct {
+	vram *vramController
+	fifo *pixelFIFO
+	cgb  bool
+
+	lcdc    uint8
+	tileMap uint32 // base address of the tile map being fetched from
+	mapX    uint8  // tile column of the next fetch
+	y       uint8  // line within the tile map
+
+	state int
+	dots  int // dots spent on the current step
+
+	tile, attrs, lo, hi uint8
+}
+
+func newBGFetcher(vram *vramController, fifo *pixelFIFO, cgb bool) *bgFetcher {
+	return &bgFetcher{vram: vram, fifo: fifo, cgb: cgb}
+}
+
+// start begins fetching line y of the given tile map, from tile column mapX,
+// with tile data addressed as LCDC selects.
+func (f *bgFetcher) start(lcdc uint8, tileMap uint32, mapX, y uint8) {
+	f.lcdc, f.tileMap, f.mapX, f.y = lcdc, tileMap, mapX, y
+	f.state, f.dots = gbFetchTile, 0
+}
+
+// Tick moves the fetcher forward by the given number of dots.
+func (f *bgFetcher) Tick(dots int) {
+	for i := 0; i < dots; i++ {
+		f.tick()
+	}
+}
+
+func (f *bgFetcher) tick() {
+	f.dots++
+	if f.dots < gbFetchStepDots {
+		return
+	}
+
+	switch f.state {
+	case gbFetchTile:
+		mapAddr := f.tileMap + uint32(f.y/8)*gbTileMapWidth + uint32(f.mapX%gbTileMapWidth)
+		f.tile = f.vram.bankByte(0, mapAddr)
+		f.attrs = 0
+		if f.cgb {
+			f.attrs = f.vram.bankByte(1, mapAddr)
+		}
+
+	case gbFetchDataLo:
+		bank, addr := f.rowAddr()
+		f.lo = f.vram.bankByte(bank, addr)
+
+	case gbFetchDataHi:
+		bank, addr := f.rowAddr()
+		f.hi = f.vram.bankByte(bank, addr+1)
+
+	case gbFetchPush:
+		if f.fifo.len() > 0 {
+			return // try again next dot
+		}
+		f.push()
+		f.mapX++
+	}
+
+	f.state = (f.state + 1) % (gbFetchPush + 1)
+	f.dots = 0
+}
+
+// rowAddr returns the bank and address of the row of the current tile that's
+// on the fetcher's line.
+func (f *bgFetcher) rowAddr() (int, uint32) {
+	row := f.y % 8
+	if f.attrs&gbBGAttrYFlip != 0 {
+		row = 7 - row
+	}
+
+	bank := 0
+	if f.attrs&gbBGAttrBank != 0 {
+		bank = 1
+	}
+
+	return bank, tileAddr(f.lcdc, f.tile) + uint32(row)*2
+}
+
+// push pushes the fetched row into the FIFO, leftmost pixel first.
+func (f *bgFetcher) push() {
+	for x := uint8(0); x < 8; x++ {
+		bit := 7 - x
+		if f.attrs&gbBGAttrXFlip != 0 {
+			bit = x
+		}
+
+		f.fifo.push(pixelFIFOEntry{
+			color:    (f.hi>>bit&1)<<1 | f.lo>>bit&1,
+			palette:  f.attrs & gbBGAttrPalette,
+			priority: f.attrs&gbBGAttrPriority != 0,
+		})
+	}
+}
+
+// objFetcher fetches the row of an object that's on the current line, while
+// the background fetcher is paused, and merges it into the object FIFO.
+type objFetcher struct {
+	vram *vramController
+	fifo *pixelFIFO
+	cgb  bool
+
+	lcdc  uint8
+	entry []uint8 // the object's OAM entry
+	row   int     // row of the object on the current line
+	dots  int     // dots left until the fetch is done, or 0 if idle
+}
+
+func newOBJFetcher(vram *vramController, fifo *pixelFIFO, cgb bool) *objFetcher {
+	return &objFetcher{vram: vram, fifo: fifo, cgb: cgb}
+}
+
+// start begins fetching the given row of the object with the given OAM
+// entry, counting from the top of the object, with its size as LCDC selects.
+func (f *objFetcher) start(lcdc uint8, entry []uint8, row int) {
+	f.lcdc, f.entry, f.row = lcdc, entry, row
+	f.dots = gbFetchOBJDots
+}
+
+// busy returns true while a fetch is in progress.
+func (f *objFetcher) busy() bool {
+	return f.dots > 0
+}
+
+// Tick moves the fetcher forward by the given number of dots, merging the
+// row into the FIFO when the fetch is done.
+func (f *objFetcher) Tick(dots int) {
+	for i := 0; i < dots && f.dots > 0; i++ {
+		f.dots--
+		if f.dots == 0 {
+			f.push()
+		}
+	}
+}
+
+// push merges the object's row into the FIFO. Objects partly off the left of
+// the screen lose the pixels that are off it.
+func (f *objFetcher) push() {
+	tile, attrs := f.entry[2], f.entry[3]
+	if !f.cgb {
+		attrs &^= gbBGAttrBank
+	}
+
+	// Tall objects are made of an even tile on top of an odd one, and flip
+	// as a whole.
+	height := gbOBJHeight
+	if f.lcdc&gbLCDCOBJSize != 0 {
+		height *= 2
+		tile &^= 0x1
+	}
+	row := f.row
+	if attrs&gbBGAttrYFlip != 0 {
+		row = height - 1 - row
+	}
+	tile += uint8(row / gbOBJHeight)
+
+	bank := 0
+	if attrs&gbBGAttrBank != 0 {
+		bank = 1
+	}
+	addr := tileAddr(gbLCDCTileData, tile) + uint32(row%gbOBJHeight)*2
+	lo, hi := f.vram.bankByte(bank, addr), f.vram.bankByte(bank, addr+1)
+
+	palette := attrs & gbOBJAttrPalette
+	if !f.cgb {
+		palette = (attrs & gbOBJAttrDMGPalette) >> 4
+	}
+
+	var pixels [8]pixelFIFOEntry
+	for x := uint8(0); x < 8; x++ {
+		bit := 7 - x
+		if attrs&gbBGAttrXFlip != 0 {
+			bit = x
+		}
+
+		pixels[x] = pixelFIFOEntry{
+			color:    (hi>>bit&1)<<1 | lo>>bit&1,
+			palette:  palette,
+			priority: attrs&gbBGAttrPriority != 0,
+		}
+	}
+
+	skip := 0
+	if x := int(f.entry[1]); x < gbOBJOffsetX {
+		skip = gbOBJOffsetX - x
+	}
+	f.fifo.merge(pixels[skip:])
+}
+
+// gbBGFetcherState is the serialisable internal state of a bgFetcher.
+type gbBGFetcherState struct {
+	LCDC                uint8
+	TileMap             uint32
+	MapX, Y             uint8
+	State, Dots         int
+	Tile, Attrs, Lo, Hi uint8
+}
+
+func (f *bgFetcher) snapshot() gbBGFetcherState {
+	return gbBGFetcherState{
+		LCDC:    f.lcdc,
+		TileMap: f.tileMap,
+		MapX:    f.mapX,
+		Y:       f.y,
+		State:   f.state,
+		Dots:    f.dots,
+		Tile:    f.tile,
+		Attrs:   f.attrs,
+		Lo:      f.lo,
+		Hi:      f.hi,
+	}
+}
+
+func (f *bgFetcher) restore(s gbBGFetcherState) {
+	f.lcdc, f.tileMap, f.mapX, f.y = s.LCDC, s.TileMap, s.MapX, s.Y
+	f.state, f.dots = s.State, s.Dots
+	f.tile, f.attrs, f.lo, f.hi = s.Tile, s.Attrs, s.Lo, s.Hi
+}
+
+// gbOBJFetcherState is the serialisable internal state of an objFetcher.
+type gbOBJFetcherState struct {
+	LCDC      uint8
+	Entry     []uint8
+	Row, Dots int
+}
+
+func (f *objFetcher) snapshot() gbOBJFetcherState {
+	return gbOBJFetcherState{
+		LCDC:  f.lcdc,
+		Entry: append([]uint8(nil), f.entry...),
+		Row:   f.row,
+		Dots:  f.dots,
+	}
+}
+
+func (f *objFetcher) restore(s gbOBJFetcherState) {
+	f.lcdc, f.entry, f.row, f.dots = s.LCDC, s.Entry, s.Row, s.Dots
+}
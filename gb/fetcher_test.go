@@ -0,0 +1,71 @@
+package gb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// popPixels pops every pixel in the FIFO and returns their colours.
+func popPixels(f *pixelFIFO) []uint8 {
+	var colors []uint8
+	for f.len() > 0 {
+		e, _ := f.pop()
+		colors = append(colors, e.color)
+	}
+
+	return colors
+}
+
+// TestBGFetcher tests fetching a row of a background tile into the FIFO.
+func TestBGFetcher(t *testing.T) {
+	vram := newVRAMController()
+	assert.NoError(t, vram.poke(gbAddrTileMap0+gbTileMapWidth+2, 0x01))
+
+	// Row 3 of tile 1 is colours 0, 1, 2, 3, 3, 2, 1, 0.
+	assert.NoError(t, vram.poke(0x8010+3*2, 0x5A))   // 0b01011010
+	assert.NoError(t, vram.poke(0x8010+3*2+1, 0x3C)) // 0b00111100
+
+	var fifo pixelFIFO
+	f := newBGFetcher(vram, &fifo, false)
+	f.start(0x91, gbAddrTileMap0, 2, 11)
+
+	// Nothing is pushed until the last step.
+	f.Tick(7)
+	assert.Equal(t, 0, fifo.len())
+	f.Tick(1)
+	assert.Equal(t, []uint8{0, 1, 2, 3, 3, 2, 1, 0}, popPixels(&fifo))
+	assert.Equal(t, uint8(3), f.mapX)
+
+	// The next push waits for the FIFO to empty.
+	f.Tick(8)
+	assert.Equal(t, 8, fifo.len())
+	f.Tick(8)
+	assert.Equal(t, 8, fifo.len())
+	popPixels(&fifo)
+	f.Tick(1)
+	assert.Equal(t, 8, fifo.len())
+}
+
+// TestBGFetcherAttributes tests fetching a CGB tile with attributes.
+func TestBGFetcherAttributes(t *testing.T) {
+	vram := newVRAMController()
+
+	// Tile 0x80 in bank 1 is flipped both ways and uses palette 5, in the
+	// signed addressing mode.
+	assert.NoError(t, vram.poke(gbAddrTileMap1, 0x80))
+	assert.NoError(t, vram.poke(gbAddrVBK, 0x01))
+	assert.NoError(t, vram.poke(gbAddrTileMap1, gbBGAttrXFlip|gbBGAttrYFlip|gbBGAttrBank|gbBGAttrPriority|0x5))
+	assert.NoError(t, vram.poke(0x8800+7*2, 0xF0))
+	assert.NoError(t, vram.poke(0x8800+7*2+1, 0x00))
+
+	var fifo pixelFIFO
+	f := newBGFetcher(vram, &fifo, true)
+	f.start(0x08, gbAddrTileMap1, 0, 0)
+	f.Tick(8)
+
+	e, ok := fifo.pop()
+	assert.True(t, ok)
+	assert.Equal(t, pixelFIFOEntry{color: 0, palette: 5, priority: true}, e)
+	assert.Equal(t, []uint8{0, 0, 0, 1, 1, 1, 1}, popPixels(&fifo))
+}
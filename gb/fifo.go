@@ -0,0 +1,125 @@
+package gb
+
+const gbPixelFIFOSize = 8
+
+// pixelFIFOEntry is a pixel waiting in one of the PPU's FIFOs.
+type pixelFIFOEntry struct {
+	color   uint8 // colour index from 0 to 3, where 0 is transparent for objects
+	palette uint8 // CGB palette, or for objects on the DMG, 0 for OBP0 and 1 for OBP1
+
+	// priority puts the pixel behind background colours 1 to 3 if it's an
+	// object's, or in front of every object if it's a CGB background's.
+	priority bool
+}
+
+// pixelFIFO is a queue of up to 8 pixels, held in a ring buffer so that
+// pushing and popping never allocate.
+type pixelFIFO struct {
+	entries [gbPixelFIFOSize]pixelFIFOEntry
+	head    int // index of the oldest entry
+	size    int
+}
+
+func (f *pixelFIFO) len() int {
+	return f.size
+}
+
+func (f *pixelFIFO) clear() {
+	f.head, f.size = 0, 0
+}
+
+// push adds a pixel to the back of the queue, returning false if it's full.
+func (f *pixelFIFO) push(e pixelFIFOEntry) bool {
+	if f.size == gbPixelFIFOSize {
+		return false
+	}
+
+	f.entries[(f.head+f.size)%gbPixelFIFOSize] = e
+	f.size++
+
+	return true
+}
+
+// pop removes the pixel at the front of the queue, returning false if it's
+// empty.
+func (f *pixelFIFO) pop() (pixelFIFOEntry, bool) {
+	if f.size == 0 {
+		return pixelFIFOEntry{}, false
+	}
+
+	e := f.entries[f.head]
+	f.head = (f.head + 1) % gbPixelFIFOSize
+	f.size--
+
+	return e, true
+}
+
+// merge mixes a row of up to 8 object pixels into the front of the queue,
+// filling it up with transparent pixels first. Pixels already in the queue are from
+// objects that take priority, so only their transparent pixels are replaced.
+func (f *pixelFIFO) merge(row []pixelFIFOEntry) {
+	for f.size < len(row) {
+		f.push(pixelFIFOEntry{})
+	}
+
+	for i, e := range row {
+		old := &f.entries[(f.head+i)%gbPixelFIFOSize]
+		if old.color == 0 {
+			*old = e
+		}
+	}
+}
+
+// mixPixel pops a pixel from each FIFO, and returns the one that ends up on
+// screen and whether it's an object's. An object's pixel wins unless it's
+// transparent, or either pixel's priority puts the background in front of a
+// background colour other than 0. An empty object FIFO counts as
+// transparent, but nothing can be drawn until the background FIFO has a
+// pixel, in which case ok is false.
+func mixPixel(bg, obj *pixelFIFO) (px pixelFIFOEntry, isObj, ok bool) {
+	bgPx, ok := bg.pop()
+	if !ok {
+		return pixelFIFOEntry{}, false, false
+	}
+
+	objPx, ok := obj.pop()
+	if !ok || objPx.color == 0 {
+		return bgPx, false, true
+	}
+	if (objPx.priority || bgPx.priority) && bgPx.color != 0 {
+		return bgPx, false, true
+	}
+
+	return objPx, true, true
+}
+
+// gbPixelState is the serialisable form of a pixelFIFOEntry.
+type gbPixelState struct {
+	Color, Palette uint8
+	Priority       bool
+}
+
+func (e pixelFIFOEntry) snapshot() gbPixelState {
+	return gbPixelState{Color: e.color, Palette: e.palette, Priority: e.priority}
+}
+
+func (s gbPixelState) entry() pixelFIFOEntry {
+	return pixelFIFOEntry{color: s.Color, palette: s.Palette, priority: s.Priority}
+}
+
+// snapshot returns the pixels in the queue, oldest first.
+func (f *pixelFIFO) snapshot() []gbPixelState {
+	pixels := make([]gbPixelState, f.size)
+	for i := range pixels {
+		pixels[i] = f.entries[(f.head+i)%gbPixelFIFOSize].snapshot()
+	}
+
+	return pixels
+}
+
+func (f *pixelFIFO) restore(pixels []gbPixelState) {
+	f.clear()
+	for _, px := range pixels {
+		f.push(px.entry())
+	}
+}
@@ -0,0 +1,92 @@
+package gb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestPixelFIFO tests pushing and popping pixels around the ring buffer.
+func TestPixelFIFO(t *testing.T) {
+	var f pixelFIFO
+	for i := 0; i < gbPixelFIFOSize; i++ {
+		assert.True(t, f.push(pixelFIFOEntry{color: uint8(i % 4), palette: uint8(i)}))
+	}
+	assert.False(t, f.push(pixelFIFOEntry{}))
+	assert.Equal(t, gbPixelFIFOSize, f.len())
+
+	// Half empty it and fill it back up, so that it wraps around.
+	for i := 0; i < 4; i++ {
+		e, ok := f.pop()
+		assert.True(t, ok)
+		assert.Equal(t, uint8(i), e.palette)
+	}
+	for i := 8; i < 12; i++ {
+		assert.True(t, f.push(pixelFIFOEntry{palette: uint8(i)}))
+	}
+	for i := 4; i < 12; i++ {
+		e, ok := f.pop()
+		assert.True(t, ok)
+		assert.Equal(t, uint8(i), e.palette)
+	}
+
+	_, ok := f.pop()
+	assert.False(t, ok)
+
+	f.push(pixelFIFOEntry{})
+	f.clear()
+	assert.Equal(t, 0, f.len())
+}
+
+// TestMixPixel tests picking between background and object pixels.
+func TestMixPixel(t *testing.T) {
+	var bg, obj pixelFIFO
+
+	// Objects 4 to 7 are behind the background, and objects 2 and 6 are
+	// transparent.
+	const priority = 0xF0 // 0b11110000
+	bgColors := []uint8{0, 1, 2, 3, 0, 1, 2, 3}
+	objColors := []uint8{3, 3, 0, 3, 3, 3, 0, 3}
+	for i := 0; i < gbPixelFIFOSize; i++ {
+		bg.push(pixelFIFOEntry{color: bgColors[i]})
+		obj.push(pixelFIFOEntry{
+			color:    objColors[i],
+			palette:  1,
+			priority: priority>>i&0x1 != 0,
+		})
+	}
+
+	expected := []struct {
+		color uint8
+		isObj bool
+	}{
+		{3, true}, {3, true}, {2, false}, {3, true},
+		{3, true}, {1, false}, {2, false}, {3, false},
+	}
+	for i, e := range expected {
+		px, isObj, ok := mixPixel(&bg, &obj)
+		assert.True(t, ok)
+		assert.Equal(t, e.color, px.color, "pixel %d", i)
+		assert.Equal(t, e.isObj, isObj, "pixel %d", i)
+	}
+
+	// Without any objects, the background shows through, and without any
+	// background nothing's drawn.
+	bg.push(pixelFIFOEntry{color: 2})
+	px, isObj, ok := mixPixel(&bg, &obj)
+	assert.True(t, ok)
+	assert.False(t, isObj)
+	assert.Equal(t, uint8(2), px.color)
+
+	obj.push(pixelFIFOEntry{color: 1})
+	_, _, ok = mixPixel(&bg, &obj)
+	assert.False(t, ok)
+	assert.Equal(t, 1, obj.len())
+
+	// A CGB background pixel with priority goes in front of objects.
+	bg.push(pixelFIFOEntry{color: 1, priority: true})
+	px, isObj, ok = mixPixel(&bg, &obj)
+	assert.True(t, ok)
+	assert.False(t, isObj)
+	assert.Equal(t, uint8(1), px.color)
+}
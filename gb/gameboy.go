@@ -1,19 +1,420 @@
 package gb
 
+import "time"
+
+const (
+	// gbClockHz is the frequency of the gameboy's quartz crystal, and
+	// gbDoubleSpeedClockHz the frequency the CGB's CPU runs at in
+	// double-speed mode.
+	gbClockHz            = 4194304
+	gbDoubleSpeedClockHz = 2 * gbClockHz
+
+	// gbCyclesPerFrame is the number of quartz-cycles it takes the gameboy to
+	// draw a single frame, including the vertical blanking period.
+	gbCyclesPerFrame = 70224
+
+	// gbFrameDuration is the wall-clock time a frame takes on real hardware.
+	gbFrameDuration = time.Second * gbCyclesPerFrame / gbClockHz
+)
+
 type Gameboy struct {
-	cpu cpu
-	ppu ppu
-	ram ram
+	model GameboyModel
+
+	cpu    cpu
+	ppu    ppu
+	apu    apu
+	bus    *gbMemoryBus
+	joypad *gbJoypad
+	serial *gbSerial
+	timer  *gbTimer
+	dma    *gbOAMDMA
+	cart   cartridge // nil until a ROM is loaded
+	header []uint8   // the loaded ROM's cartridge header
+	vram   *vramController
+	wram   *wramController // nil unless the model has banked work RAM
+	hdma   *gbHDMA         // nil unless the model has HDMA
+	key1   *gbKEY1         // nil unless the model has a speed switch
+
+	bgPalettes  *gbPaletteRAM // nil unless the model has colour palettes
+	objPalettes *gbPaletteRAM // nil unless the model has colour palettes
+
+	wait    int    // quartz-cycles until the CPU fetches its next instruction
+	clockHz uint32 // frequency of the CPU's clock
+	cycles  uint64 // quartz-cycles since the gameboy started
+	frames  uint64 // V-blanks since the gameboy started
+	speed   float64
+
+	frameDeadline time.Time // when the next RunFrameWithSync frame is due
+	now           func() time.Time
+	sleep         func(time.Duration)
+
+	audio     *gbAudioOutput // nil unless an audio callback is set
+	audioRate int
+
+	usePipeline bool // whether the PPU draws lines through its pixel FIFOs
+
+	rewind   *gbRewindBuffer // nil unless rewind is enabled
+	tracer   *TraceLogger    // nil unless tracing is enabled
+	profiler *Profiler       // nil unless profiling is enabled
+
+	onVBlank     func()
+	onHBlank     func(line int)
+	onScanline   func(line int, pixels []uint8)
+	onVideo      func(framebuffer []uint8)
+	onBreakpoint func(pc uint16)
+}
+
+// Option configures a Gameboy at construction time.
+type Option func(*Gameboy)
+
+// WithModel sets the hardware model to emulate. The default is DMG.
+func WithModel(m GameboyModel) Option {
+	return func(g *Gameboy) {
+		g.model = m
+	}
 }
 
-func NewGameboy() *Gameboy {
-	return &Gameboy{
-		cpu: newGBCPU(),
-		ppu: newGBPPU(),
-		ram: newGBRAM(),
+func NewGameboy(opts ...Option) *Gameboy {
+	bus := newGBMemoryBus()
+	g := &Gameboy{
+		speed:     1,
+		model:     DMG,
+		bus:       bus,
+		joypad:    newGBJoypad(),
+		vram:      newVRAMController(),
+		audioRate: gbAudioSampleRate,
+		now:       time.Now,
+		sleep:     time.Sleep,
+	}
+	g.serial = newGBSerial(bus)
+	g.timer = newGBTimer(bus)
+	g.dma = newGBOAMDMA(bus)
+	a := newGBAPU()
+	g.apu = a
+	bus.mapRegion(gbAddrP1, gbAddrP1, g.joypad)
+	bus.mapRegion(gbAddrSB, gbAddrSC, g.serial)
+	bus.mapRegion(gbAddrDIV, gbAddrTAC, g.timer)
+	bus.mapRegion(gbAddrNR10, gbAddrNR52, a)
+	bus.mapRegion(gbAddrDMA, gbAddrDMA, g.dma)
+	bus.mapRegion(gbAddrVRAM, gbAddrVRAMEnd, newGBLockedRegion(g.vram, func() bool {
+		return g.ppu.IsVRAMAccessible()
+	}))
+
+	for _, opt := range opts {
+		opt(g)
+	}
+
+	bus.mapRegion(gbAddrOAM, gbAddrOAMEnd, newGBLockedRegion(bus.mem, func() bool {
+		return g.ppu.IsOAMAccessible()
+	}))
+
+	if g.model == SGB {
+		g.joypad.sgb = newSGBController()
+	}
+
+	p := newGBPPU(bus, g.vram)
+	p.oam = bus.mem
+	p.cgb = g.model.isCGB()
+	if g.usePipeline {
+		p.pipeline = newPixelPipeline(g.vram, p.cgb)
+	}
+	g.ppu = p
+
+	if g.model.isCGB() {
+		bus.mapRegion(gbAddrVBK, gbAddrVBK, g.vram)
+
+		g.wram = newWRAMController()
+		bus.mapRegion(gbAddrWRAM0, gbAddrWRAMXEnd, g.wram)
+		bus.mapRegion(gbAddrSVBK, gbAddrSVBK, g.wram)
+
+		g.hdma = newGBHDMA(bus)
+		bus.mapRegion(gbAddrHDMA1, gbAddrHDMA5, g.hdma)
+
+		g.bgPalettes = newGBPaletteRAM(gbAddrBCPS, gbAddrBCPD)
+		bus.mapRegion(gbAddrBCPS, gbAddrBCPD, g.bgPalettes)
+		p.bgPalettes = g.bgPalettes
+
+		g.objPalettes = newGBPaletteRAM(gbAddrOCPS, gbAddrOCPD)
+		bus.mapRegion(gbAddrOCPS, gbAddrOCPD, g.objPalettes)
+		p.objPalettes = g.objPalettes
+	}
+
+	c := newGBCPU()
+	c.cgb = g.model.isCGB()
+	loadBootRegisters(c, g.model.bootRegisters())
+	g.cpu = c
+
+	if g.model.isCGB() {
+		g.key1 = &gbKEY1{cpu: c}
+		bus.mapRegion(gbAddrKEY1, gbAddrKEY1, g.key1)
+	}
+	g.updateClock()
+
+	return g
+}
+
+// Model returns the hardware model being emulated.
+func (g *Gameboy) Model() GameboyModel {
+	return g.model
+}
+
+// FrameCount returns the number of frames since the gameboy started or was
+// last reset, counted each time the PPU enters V-blank.
+func (g *Gameboy) FrameCount() uint64 {
+	return g.frames
+}
+
+// CycleCount returns the number of quartz-cycles since the gameboy started or
+// was last reset.
+func (g *Gameboy) CycleCount() uint64 {
+	return g.cycles
+}
+
+// DumpRegisters returns a human-readable listing of the CPU's registers.
+func (g *Gameboy) DumpRegisters() string {
+	return g.cpu.DumpRegisters()
+}
+
+// Step moves the gameboy state forward by a single quartz-cycle. The CPU
+// executes an instruction at the start of its cycle window and then idles for
+// the remainder of the window.
+func (g *Gameboy) Step() error {
+	// A halted CPU idles for 4 quartz-cycles at a time until it's woken up.
+	if g.wait == 0 {
+		halted, err := g.cpu.isHalted(g.bus)
+		if err != nil {
+			return err
+		}
+		if halted {
+			g.wait = 4
+		}
+	}
+
+	if g.wait == 0 {
+		op, err := g.cpu.load(g.bus)
+		if err != nil {
+			return err
+		}
+		if g.tracer != nil {
+			g.tracer.record(g.cpu, op, g.cycles)
+		}
+		cycles, err := g.cpu.execute(g.bus, op)
+		if err != nil {
+			return err
+		}
+		g.updateClock() // STOP may have switched speed
+
+		// In double-speed mode, the CPU fits twice the cycles into the same
+		// number of quartz-cycles.
+		g.wait = 4 * cycles * gbClockHz / int(g.clockHz)
+		if g.profiler != nil {
+			g.profiler.record(op, g.wait)
+		}
+		if g.hdma != nil {
+			g.wait += g.hdma.takeStall()
+		}
+	}
+	g.wait--
+	g.cycles++
+
+	// The timer runs off the CPU's clock, so it's twice as fast in
+	// double-speed mode.
+	if err := g.timer.Tick(int(g.clockHz / gbClockHz)); err != nil {
+		return err
+	}
+
+	if err := g.dma.tick(); err != nil {
+		return err
 	}
+
+	left, right := g.apu.tick()
+	if g.audio != nil && g.speed > 0 {
+		g.audio.push(left, right, g.speed)
+	}
+
+	mode := g.ppu.Mode()
+	if err := g.ppu.Tick(1); err != nil {
+		return err
+	}
+	if g.ppu.Mode() != mode {
+		if g.hdma != nil && g.ppu.Mode() == gbPPUModeHBlank {
+			if err := g.hdma.hblank(); err != nil {
+				return err
+			}
+		}
+		if g.ppu.Mode() == gbPPUModeVBlank {
+			g.frames++
+		}
+		g.firePPUEvents(g.ppu.Mode())
+	}
+
+	return nil
+}
+
+// RunFrame moves the gameboy state forward by a single frame's worth of
+// quartz-cycles. If rewind is enabled, the state is snapshotted first.
+func (g *Gameboy) RunFrame() error {
+	if g.rewind != nil {
+		state, err := g.SaveState()
+		if err != nil {
+			return err
+		}
+		g.rewind.push(state)
+	}
+
+	for i := 0; i < gbCyclesPerFrame; i++ {
+		if err := g.Step(); err != nil {
+			return err
+		}
+	}
+
+	// Slow motion pads each frame out with a sleep.
+	if g.speed > 0 && g.speed < 1 {
+		g.sleep(time.Duration(float64(gbFrameDuration) * (1/g.speed - 1)))
+	}
+
+	return nil
+}
+
+// RunUntil steps the gameboy a quartz-cycle at a time until the condition
+// holds or maxCycles have passed, and returns the number of quartz-cycles it
+// ran for. The condition is checked before each step.
+func (g *Gameboy) RunUntil(condition func(*Gameboy) bool, maxCycles uint64) (uint64, error) {
+	var n uint64
+	for ; n < maxCycles && !condition(g); n++ {
+		if err := g.Step(); err != nil {
+			return n, err
+		}
+	}
+
+	return n, nil
+}
+
+// RunN steps the gameboy until the CPU has started n more instructions, or
+// an error occurs. The last instruction's remaining quartz-cycles are left
+// for the next step. A halted CPU counts each of its idle windows as an
+// instruction.
+func (g *Gameboy) RunN(n int) error {
+	for n > 0 {
+		if g.wait == 0 {
+			n--
+		}
+		if err := g.Step(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RunFrameWithSync runs a frame like RunFrame, and then sleeps until the frame
+// is due to finish on real hardware, at about 59.73 frames a second.
+// Deadlines are spaced a fixed period apart rather than measured from when
+// each frame finishes, so that oversleeping on one frame is made up on the
+// next. If emulation falls more than a frame behind, it starts afresh rather
+// than rushing to catch up.
+func (g *Gameboy) RunFrameWithSync() error {
+	if g.frameDeadline.IsZero() {
+		g.frameDeadline = g.now()
+	}
+
+	if err := g.RunFrame(); err != nil {
+		return err
+	}
+
+	g.frameDeadline = g.frameDeadline.Add(gbFrameDuration)
+	late := g.now().Sub(g.frameDeadline)
+	switch {
+	case late < 0:
+		g.sleep(-late)
+
+	case late > gbFrameDuration:
+		g.frameDeadline = g.now()
+	}
+
+	return nil
+}
+
+// SetSpeed changes how RunFrame paces emulation relative to real hardware.
+// Factors below 1 slow emulation down by sleeping after each frame. Factors
+// above 1 are for turbo modes, which skip audio samples so that each frame's
+// audio plays in a fraction of a frame. A factor of 0 runs without pacing and
+// stops the video and audio callbacks from firing, dropping the samples, but
+// the framebuffer is still drawn. The emulation itself runs the same number
+// of cycles per frame at any speed.
+func (g *Gameboy) SetSpeed(factor float64) {
+	if factor < 0 {
+		factor = 0
+	}
+
+	g.speed = factor
+}
+
+// SetHeadless turns the PPU's rendering off or on. While it's off, the PPU
+// still moves through its modes and scanlines and fires its events, but
+// nothing is drawn into the framebuffers. This is useful for running test
+// ROMs as fast as possible.
+func (g *Gameboy) SetHeadless(headless bool) {
+	g.ppu.setHeadless(headless)
+}
+
+// Reset returns the gameboy to the state it was in when it was created,
+// clearing memory and reloading the model's boot registers. Callbacks, rewind
+// and tracing settings are kept, as are the loaded cartridge and anything
+// plugged into the serial port.
+func (g *Gameboy) Reset() {
+	g.bus.mem.Clear()
+	g.ppu.restore(gbPPUState{Mode: gbPPUModeOAMScan})
+	g.apu.restore(gbAPUState{})
+	g.joypad.selectBits = gbP1SelectMask
+	if g.joypad.sgb != nil {
+		g.joypad.sgb.reset()
+	}
+	g.serial.sb, g.serial.sc = 0, 0
+	g.timer.restore(gbTimerState{})
+	g.dma.restore(gbOAMDMAState{})
+	if g.cart != nil {
+		g.cart.reset()
+	}
+	*g.vram = vramController{}
+	if g.wram != nil {
+		*g.wram = wramController{}
+	}
+	if g.hdma != nil {
+		g.hdma.restore(gbHDMAState{})
+	}
+	if g.key1 != nil {
+		g.key1.restore(0)
+	}
+	if g.bgPalettes != nil {
+		g.bgPalettes.clear()
+		g.objPalettes.clear()
+	}
+	g.wait, g.cycles, g.frames = 0, 0, 0
+
+	g.cpu.restore(gbCPUState{})
+	loadBootRegisters(g.cpu, g.model.bootRegisters())
+	g.updateClock()
+}
+
+// GetFramebuffer returns a copy of the last frame drawn by the PPU, as 160x144
+// colour indices from 0 to 3, row by row from the top left.
+func (g *Gameboy) GetFramebuffer() []uint8 {
+	return append([]uint8(nil), g.ppu.Framebuffer()...)
+}
+
+// GetColorFramebuffer returns a copy of the last frame drawn by the PPU in
+// colour, as 160x144 BGR555 values, row by row from the top left. Only CGB
+// models draw in colour - for other models the frame is blank.
+func (g *Gameboy) GetColorFramebuffer() []uint16 {
+	return append([]uint16(nil), g.ppu.colorFramebuffer()...)
 }
 
-// Step moves the gameboy state forward by a single quartz-cycle.
-func (g *Gameboy) Step() {
+func loadBootRegisters(c cpu, br gbBootRegisters) {
+	c.pokeRegister(br.af, gbRegisterAF)
+	c.pokeRegister(br.bc, gbRegisterBC)
+	c.pokeRegister(br.de, gbRegisterDE)
+	c.pokeRegister(br.hl, gbRegisterHL)
+	c.pokeRegister(br.sp, gbRegisterSP)
+	c.pokeRegister(br.pc, gbRegisterPC)
 }
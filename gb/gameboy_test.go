@@ -0,0 +1,280 @@
+package gb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testProgram is a short program exercising memory and registers in a loop.
+var testProgram = []uint8{
+	0x26, 0xC0, // 0x100: LD H, 0xC0
+	0x2E, 0x10, // 0x102: LD L, 0x10
+	0x36, 0x42, // 0x104: LD (HL), 0x42
+	0x46,       // 0x106: LD B, (HL)
+	0x48,       // 0x107: LD C, B
+	0x18, 0xFA, // 0x108: JR -6
+}
+
+// prepareGameboy provides a DMG gameboy with the given program written to the
+// cartridge entry point at 0x100.
+func prepareGameboy(t *testing.T, program []uint8) *Gameboy {
+	g := NewGameboy()
+	assert.NoError(t, pokeN(g.bus, 0x100, program))
+
+	return g
+}
+
+// runFrames runs the given gameboy for n frames.
+func runFrames(t *testing.T, g *Gameboy, n int) {
+	for i := 0; i < n; i++ {
+		if !assert.NoError(t, g.RunFrame()) {
+			return
+		}
+	}
+}
+
+// runInstructions steps the given gameboy until it has executed n
+// instructions.
+func runInstructions(t *testing.T, g *Gameboy, n int) {
+	assert.NoError(t, g.RunN(n))
+}
+
+// TestRunN tests running the gameboy a number of instructions at a time.
+func TestRunN(t *testing.T) {
+	g := prepareGameboy(t, []uint8{
+		0x00,       // 0x100: NOP
+		0x18, 0x00, // 0x101: JR 0
+		0x00, // 0x103: NOP
+	})
+
+	assert.NoError(t, g.RunN(0))
+	assert.Equal(t, uint64(0), g.CycleCount())
+	assert.Equal(t, uint16(0x100), g.cpu.readRegister(gbRegisterPC))
+
+	assert.NoError(t, g.RunN(2))
+	assert.Equal(t, uint16(0x103), g.cpu.readRegister(gbRegisterPC))
+	assert.Equal(t, uint64(4+1), g.CycleCount())
+	assert.Equal(t, 4*3-1, g.wait)
+}
+
+// TestGameboyModelBootRegisters tests that the boot register state depends on
+// the configured hardware model.
+func TestGameboyModelBootRegisters(t *testing.T) {
+	dmg := NewGameboy(WithModel(DMG))
+	cgb := NewGameboy(WithModel(CGB))
+
+	assert.Equal(t, DMG, dmg.Model())
+	assert.Equal(t, CGB, cgb.Model())
+	assert.Equal(t, uint16(0x01), dmg.cpu.readRegister(gbRegisterA))
+	assert.Equal(t, uint16(0x11), cgb.cpu.readRegister(gbRegisterA))
+	assert.Equal(t, uint16(0x0100), dmg.cpu.readRegister(gbRegisterPC))
+	assert.Equal(t, uint16(0xFFFE), cgb.cpu.readRegister(gbRegisterSP))
+}
+
+// TestGameboyDefaultModel tests that gameboys are DMG models by default.
+func TestGameboyDefaultModel(t *testing.T) {
+	g := NewGameboy()
+	assert.Equal(t, DMG, g.Model())
+	assert.Equal(t, 2, g.model.wramBanks())
+	assert.Equal(t, 8, CGB.wramBanks())
+}
+
+// TestSetSpeed tests that slow motion paces frames, that turbo skips audio
+// samples, and that a speed of 0 doesn't block or produce any output but
+// still draws the framebuffer.
+func TestSetSpeed(t *testing.T) {
+	g := prepareBackground(t, DMG)
+	fillTile(t, g, 0x8010, 3)
+	c := &fakeClock{t: time.Unix(0, 0)}
+	g.now, g.sleep = c.now, c.sleep
+
+	// At a tenth of the speed, each frame sleeps for another 9 frames.
+	g.SetSpeed(0.1)
+	runFrames(t, g, 1)
+	assert.InDelta(t, float64(9*gbFrameDuration), float64(c.slept), float64(time.Millisecond))
+	assert.Equal(t, uint64(gbCyclesPerFrame), g.cycles)
+
+	frames, samples, last := 0, 0, 0
+	g.SetVideoCallback(func([]uint8) { frames++ })
+	g.SetAudioCallback(func(left, _ []float32) {
+		samples += len(left)
+		last = len(left)
+	})
+	g.SetSpeed(0)
+	c.slept = 0
+	g.ppu.Framebuffer()[0] = 0
+	runFrames(t, g, 1)
+	assert.Equal(t, time.Duration(0), c.slept)
+	assert.Equal(t, uint64(2*gbCyclesPerFrame), g.cycles)
+	assert.Equal(t, 0, frames)
+	assert.Equal(t, 0, samples)
+	assert.Equal(t, uint8(3), g.GetFramebuffer()[0])
+
+	// Output starts again at normal speed.
+	g.SetSpeed(1)
+	runFrames(t, g, 2)
+	assert.Equal(t, 2, frames)
+	assert.True(t, samples > 0)
+	normal := last
+
+	// At double speed, a frame's audio is half as long.
+	g.SetSpeed(2)
+	runFrames(t, g, 2)
+	assert.Equal(t, 4, frames)
+	assert.InDelta(t, normal/2, last, 2)
+}
+
+// TestRunUntil tests running until a condition holds or time runs out.
+func TestRunUntil(t *testing.T) {
+	g := prepareGameboy(t, testProgram)
+	line10 := func(g *Gameboy) bool { return g.ppu.Scanline() == 10 }
+
+	n, err := g.RunUntil(line10, gbCyclesPerFrame)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(10*gbDotsPerLine), n)
+
+	// The condition already holds, so nothing runs.
+	n, err = g.RunUntil(line10, gbCyclesPerFrame)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), n)
+
+	n, err = g.RunUntil(func(*Gameboy) bool { return false }, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(100), n)
+	assert.Equal(t, uint64(10*gbDotsPerLine+100), g.cycles)
+}
+
+// TestFrameCount tests that frames are counted as V-blank begins.
+func TestFrameCount(t *testing.T) {
+	g := prepareGameboy(t, testProgram)
+	runFrames(t, g, 3)
+	assert.Equal(t, uint64(3), g.FrameCount())
+
+	// Moving through the rest of V-blank doesn't count another frame.
+	line := func(ly int) func(*Gameboy) bool {
+		return func(g *Gameboy) bool { return g.ppu.Scanline() == ly }
+	}
+	_, err := g.RunUntil(line(gbVisibleLines+1), gbCyclesPerFrame)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(4), g.FrameCount())
+	_, err = g.RunUntil(line(gbLinesPerFrame-1), gbCyclesPerFrame)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(4), g.FrameCount())
+
+	g.Reset()
+	assert.Equal(t, uint64(0), g.FrameCount())
+}
+
+// TestCycleCount tests that quartz-cycles are counted across instructions
+// and save states.
+func TestCycleCount(t *testing.T) {
+	g := prepareGameboy(t, testProgram)
+	runInstructions(t, g, 5)
+	for g.wait > 0 {
+		assert.NoError(t, g.Step())
+	}
+
+	// LD H,n + LD L,n + LD (HL),n + LD B,(HL) + LD C,B
+	assert.Equal(t, uint64(8+8+12+8+4), g.CycleCount())
+
+	state, err := g.SaveState()
+	assert.NoError(t, err)
+	other := NewGameboy()
+	assert.NoError(t, other.LoadState(state))
+	assert.Equal(t, g.CycleCount(), other.CycleCount())
+}
+
+// fakeClock stands in for the wall clock in RunFrameWithSync. Sleeping moves
+// it forward by the time slept, and each call to now moves it forward by
+// step, to stand in for the time spent emulating.
+type fakeClock struct {
+	t     time.Time
+	step  time.Duration
+	slept time.Duration
+}
+
+func (c *fakeClock) now() time.Time {
+	c.t = c.t.Add(c.step)
+	return c.t
+}
+
+func (c *fakeClock) sleep(d time.Duration) {
+	c.t = c.t.Add(d)
+	c.slept += d
+}
+
+// TestRunFrameWithSync tests that synced frames run at the hardware's frame
+// rate, and that falling behind starts the deadlines afresh.
+func TestRunFrameWithSync(t *testing.T) {
+	g := prepareGameboy(t, testProgram)
+	c := &fakeClock{t: time.Unix(0, 0), step: time.Millisecond}
+	g.now, g.sleep = c.now, c.sleep
+
+	start := c.t
+	for i := 0; i < 10; i++ {
+		if !assert.NoError(t, g.RunFrameWithSync()) {
+			return
+		}
+	}
+	// The first deadline is set by the first reading of the clock, a step in.
+	assert.Equal(t, start.Add(c.step+10*gbFrameDuration), c.t)
+
+	// A frame that takes too long isn't made up for by skipping sleeps.
+	c.step = 3 * gbFrameDuration
+	c.slept = 0
+	assert.NoError(t, g.RunFrameWithSync())
+	assert.Equal(t, time.Duration(0), c.slept)
+	assert.Equal(t, c.t, g.frameDeadline)
+
+	c.step = 0
+	assert.NoError(t, g.RunFrameWithSync())
+	assert.Equal(t, gbFrameDuration, c.slept)
+}
+
+// TestSetHeadless tests that the PPU keeps moving through scanlines without
+// drawing anything in headless mode.
+func TestSetHeadless(t *testing.T) {
+	g := prepareBackground(t, DMG)
+	fillTile(t, g, 0x8000, 3)
+	g.SetHeadless(true)
+
+	ly := -1
+	g.OnVBlank(func() { ly = g.ppu.Scanline() })
+	runFrames(t, g, 1)
+
+	assert.Equal(t, gbVisibleLines, ly)
+	assert.Equal(t, make([]uint8, gbScreenWidth*gbScreenHeight), g.GetFramebuffer())
+}
+
+// TestReset tests that resetting a gameboy returns it to its initial state.
+func TestReset(t *testing.T) {
+	g := prepareGameboy(t, testProgram)
+	runFrames(t, g, 1)
+
+	g.Reset()
+	assert.Equal(t, uint64(0), g.cycles)
+	assert.Equal(t, uint16(0x0100), g.cpu.readRegister(gbRegisterPC))
+	assert.Equal(t, uint16(0x0013), g.cpu.readRegister(gbRegisterBC))
+	assert.Equal(t, 0, g.ppu.Scanline())
+
+	val, err := g.bus.read(0xC010)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0x00), val)
+}
+
+// TestStepHalted tests that a halted CPU idles until an interrupt is pending.
+func TestStepHalted(t *testing.T) {
+	g := prepareGameboy(t, []uint8{0x76, 0x00, 0x00})
+	assert.NoError(t, g.bus.poke(gbAddrIE, 0x01))
+
+	for i := 0; i < 100; i++ {
+		assert.NoError(t, g.Step())
+	}
+	assert.Equal(t, uint16(0x101), g.cpu.readRegister(gbRegisterPC))
+
+	assert.NoError(t, g.bus.poke(gbAddrIF, 0x01))
+	runInstructions(t, g, 1)
+	assert.Equal(t, uint16(0x102), g.cpu.readRegister(gbRegisterPC))
+}
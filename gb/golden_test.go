@@ -0,0 +1,111 @@
+package gb
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// gbGoldenState is a CPU and memory state in the sm83-test-data JSON format.
+// Memory is a list of [address, value] pairs, and only the listed addresses
+// are set or checked.
+type gbGoldenState struct {
+	PC  uint16      `json:"pc"`
+	SP  uint16      `json:"sp"`
+	A   uint8       `json:"a"`
+	B   uint8       `json:"b"`
+	C   uint8       `json:"c"`
+	D   uint8       `json:"d"`
+	E   uint8       `json:"e"`
+	F   uint8       `json:"f"`
+	H   uint8       `json:"h"`
+	L   uint8       `json:"l"`
+	IME uint8       `json:"ime"`
+	IE  uint8       `json:"ie"`
+	RAM [][2]uint16 `json:"ram"`
+}
+
+func (s gbGoldenState) snapshot() CPUSnapshot {
+	return CPUSnapshot{
+		A: s.A, F: s.F, B: s.B, C: s.C, D: s.D, E: s.E, H: s.H, L: s.L,
+		SP: s.SP, PC: s.PC,
+	}
+}
+
+// gbGoldenTest is a single instruction test in the sm83-test-data format.
+// The cycles list holds one entry per machine cycle the instruction takes.
+type gbGoldenTest struct {
+	Name    string            `json:"name"`
+	Initial gbGoldenState     `json:"initial"`
+	Final   gbGoldenState     `json:"final"`
+	Cycles  []json.RawMessage `json:"cycles"`
+}
+
+// TestCPUGolden runs the instruction tests in testdata/cpu, one file per
+// opcode. Files from the sm83-test-data suite can be dropped in as they are.
+// Tests for opcodes that haven't been implemented yet are skipped.
+func TestCPUGolden(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "cpu", "*.json"))
+	if !assert.NoError(t, err) || len(paths) == 0 {
+		t.Skip("no golden files")
+	}
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var tests []gbGoldenTest
+		if err := json.Unmarshal(data, &tests); err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+
+		for _, test := range tests {
+			t.Run(test.Name, func(t *testing.T) {
+				runGoldenTest(t, test)
+			})
+		}
+	}
+}
+
+func runGoldenTest(t *testing.T, test gbGoldenTest) {
+	c := newGBCPU()
+	r := newGBRAM()
+
+	s := test.Initial
+	c.pokeRegister(uint16(s.A)<<8|uint16(s.F), gbRegisterAF)
+	c.pokeRegister(uint16(s.B)<<8|uint16(s.C), gbRegisterBC)
+	c.pokeRegister(uint16(s.D)<<8|uint16(s.E), gbRegisterDE)
+	c.pokeRegister(uint16(s.H)<<8|uint16(s.L), gbRegisterHL)
+	c.pokeRegister(s.SP, gbRegisterSP)
+	c.pokeRegister(s.PC, gbRegisterPC)
+	c.ime = s.IME != 0
+	assert.NoError(t, r.poke(gbAddrIE, s.IE))
+	for _, kv := range s.RAM {
+		assert.NoError(t, r.poke(uint32(kv[0]), uint8(kv[1])))
+	}
+
+	cycles, err := runInstructionCycle(c, r)
+	var unknownErr *UnknownOpcodeError
+	if errors.As(err, &unknownErr) {
+		t.Skip(err)
+	}
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	expected := test.Final.snapshot()
+	assert.Empty(t, expected.Diff(NewCPUSnapshot(c)), "registers (expected/actual)")
+	assert.Equal(t, test.Final.IME != 0, c.ime, "IME")
+	assert.Equal(t, len(test.Cycles), cycles, "machine cycles")
+	for _, kv := range test.Final.RAM {
+		val, err := r.read(uint32(kv[0]))
+		assert.NoError(t, err)
+		assert.Equal(t, uint8(kv[1]), val, "memory at 0x%04X", kv[0])
+	}
+}
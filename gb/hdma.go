@@ -0,0 +1,154 @@
+package gb
+
+import "errors"
+
+const (
+	gbAddrHDMA1 uint32 = 0xFF51 // source, high byte
+	gbAddrHDMA2 uint32 = 0xFF52 // source, low byte
+	gbAddrHDMA3 uint32 = 0xFF53 // destination, high byte
+	gbAddrHDMA4 uint32 = 0xFF54 // destination, low byte
+	gbAddrHDMA5 uint32 = 0xFF55 // length, mode and start
+
+	gbHDMABlockSize          = 16
+	gbHDMABlockCycles        = 32   // quartz-cycles to copy a block
+	gbHDMAModeHBlank  uint8  = 0x80 // 0b10000000
+	gbHDMALengthMask  uint8  = 0x7F // 0b01111111
+	gbHDMADstMask     uint16 = 0x1FF0
+	gbHDMASrcMask     uint16 = 0xFFF0
+)
+
+var (
+	gbErrHDMAAddress = errors.New("gbHDMA: address isn't an HDMA register")
+)
+
+// gbHDMA copies data into video RAM on the CGB. A general-purpose transfer
+// copies everything at once, stalling the CPU while it runs. An H-blank
+// transfer copies one 16 byte block at the start of each H-blank instead.
+type gbHDMA struct {
+	mem ram
+
+	src, dst  uint16
+	remaining int  // blocks left to copy
+	active    bool // an H-blank transfer is in progress
+	stall     int  // quartz-cycles the CPU owes for general-purpose transfers
+}
+
+// gbHDMAState is the serialisable internal state of a gbHDMA.
+type gbHDMAState struct {
+	Src, Dst  uint16
+	Remaining int
+	Active    bool
+}
+
+func newGBHDMA(mem ram) *gbHDMA {
+	return &gbHDMA{mem: mem}
+}
+
+func (h *gbHDMA) snapshot() gbHDMAState {
+	return gbHDMAState{h.src, h.dst, h.remaining, h.active}
+}
+
+func (h *gbHDMA) restore(s gbHDMAState) {
+	h.src, h.dst, h.remaining, h.active = s.Src, s.Dst, s.Remaining, s.Active
+	h.stall = 0
+}
+
+func (h *gbHDMA) poke(addr uint32, val uint8) error {
+	switch addr {
+	case gbAddrHDMA1:
+		h.src = uint16(val)<<8 | h.src&0xFF
+	case gbAddrHDMA2:
+		h.src = (h.src&0xFF00 | uint16(val)) & gbHDMASrcMask
+	case gbAddrHDMA3:
+		h.dst = (uint16(val)<<8 | h.dst&0xFF) & gbHDMADstMask
+	case gbAddrHDMA4:
+		h.dst = (h.dst&0xFF00 | uint16(val)) & gbHDMADstMask
+
+	case gbAddrHDMA5:
+		// Writing with bit 7 clear during an H-blank transfer cancels it.
+		if h.active && val&gbHDMAModeHBlank == 0 {
+			h.active = false
+			return nil
+		}
+
+		h.remaining = int(val&gbHDMALengthMask) + 1
+		if val&gbHDMAModeHBlank != 0 {
+			h.active = true
+			return nil
+		}
+
+		for h.remaining > 0 {
+			if err := h.copyBlock(); err != nil {
+				return err
+			}
+			h.stall += gbHDMABlockCycles
+		}
+
+	default:
+		return gbErrHDMAAddress
+	}
+
+	return nil
+}
+
+func (h *gbHDMA) read(addr uint32) (uint8, error) {
+	switch addr {
+	case gbAddrHDMA1, gbAddrHDMA2, gbAddrHDMA3, gbAddrHDMA4:
+		return 0xFF, nil // write-only
+
+	case gbAddrHDMA5:
+		// Bit 7 is set when no H-blank transfer is in progress, and the low
+		// bits count the remaining blocks minus one.
+		length := uint8(h.remaining-1) & gbHDMALengthMask
+		if !h.active {
+			return gbHDMAModeHBlank | length, nil
+		}
+		return length, nil
+	}
+
+	return 0, gbErrHDMAAddress
+}
+
+func (h *gbHDMA) ReadSlice(addr, n uint32) ([]uint8, error) {
+	return readN(h, addr, n)
+}
+
+// hblank copies the next block of an H-blank transfer, if one is in progress.
+func (h *gbHDMA) hblank() error {
+	if !h.active {
+		return nil
+	}
+
+	if err := h.copyBlock(); err != nil {
+		return err
+	}
+	if h.remaining == 0 {
+		h.active = false
+	}
+
+	return nil
+}
+
+// takeStall returns the quartz-cycles the CPU must wait for transfers that
+// have run since the last call.
+func (h *gbHDMA) takeStall() int {
+	stall := h.stall
+	h.stall = 0
+
+	return stall
+}
+
+// copyBlock copies the next 16 bytes of the transfer into video RAM.
+func (h *gbHDMA) copyBlock() error {
+	// Blocks are aligned, so they never wrap around the end of video RAM.
+	dst := gbAddrVRAM + uint32(h.dst&(gbVRAMBankSize-1))
+	if err := copyRegion(h.mem, h.mem, uint32(h.src), dst, gbHDMABlockSize); err != nil {
+		return err
+	}
+
+	h.src += gbHDMABlockSize
+	h.dst = (h.dst + gbHDMABlockSize) & gbHDMADstMask
+	h.remaining--
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package gb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// prepareHDMA provides a CGB gameboy running the given program, with 32 bytes
+// of source data at 0xD000 and HDMA set up to copy it to 0x8100.
+func prepareHDMA(t *testing.T, program []uint8) *Gameboy {
+	g := NewGameboy(WithModel(CGB))
+	assert.NoError(t, pokeN(g.bus, 0x100, program))
+	for i := uint32(0); i < 32; i++ {
+		assert.NoError(t, g.bus.poke(0xD000+i, uint8(i+1)))
+	}
+
+	assert.NoError(t, pokeN(g.bus, gbAddrHDMA1, []uint8{0xD0, 0x00, 0x01, 0x00}))
+	return g
+}
+
+// assertHDMACopied asserts that the source data has been copied to VRAM.
+func assertHDMACopied(t *testing.T, g *Gameboy, n uint32) {
+	for i := uint32(0); i < n; i++ {
+		val, err := g.bus.read(0x8100 + i)
+		assert.NoError(t, err)
+		assert.Equal(t, uint8(i+1), val)
+	}
+}
+
+// TestHDMAGeneralPurpose tests that general-purpose transfers copy all of
+// their data at once and stall the CPU while they do.
+func TestHDMAGeneralPurpose(t *testing.T) {
+	g := prepareHDMA(t, []uint8{
+		0x26, 0xFF, // LD H, 0xFF
+		0x2E, 0x55, // LD L, 0x55
+		0x36, 0x01, // LD (HL), 0x01 - copy 2 blocks
+	})
+	runInstructions(t, g, 3)
+
+	assertHDMACopied(t, g, 32)
+	hdma5, err := g.bus.read(gbAddrHDMA5)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0xFF), hdma5)
+
+	// The CPU waits for the instruction and then two blocks.
+	assert.Equal(t, 4*3+2*gbHDMABlockCycles-1, g.wait)
+}
+
+// TestHDMAHBlank tests that H-blank transfers copy a block per H-blank.
+func TestHDMAHBlank(t *testing.T) {
+	g := prepareHDMA(t, testProgram)
+	assert.NoError(t, g.bus.poke(gbAddrHDMA5, gbHDMAModeHBlank|0x01))
+
+	hdma5, err := g.bus.read(gbAddrHDMA5)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0x01), hdma5)
+
+	// Run up to the first H-blank.
+	for g.ppu.Mode() != gbPPUModeHBlank {
+		assert.NoError(t, g.Step())
+	}
+	assertHDMACopied(t, g, 16)
+	val, err := g.bus.read(0x8110)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0x00), val)
+
+	hdma5, err = g.bus.read(gbAddrHDMA5)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0x00), hdma5)
+
+	runFrames(t, g, 1)
+	assertHDMACopied(t, g, 32)
+	hdma5, err = g.bus.read(gbAddrHDMA5)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0xFF), hdma5)
+}
+
+// TestHDMAHBlankCancel tests cancelling an H-blank transfer.
+func TestHDMAHBlankCancel(t *testing.T) {
+	g := prepareHDMA(t, testProgram)
+	assert.NoError(t, g.bus.poke(gbAddrHDMA5, gbHDMAModeHBlank|0x01))
+	assert.NoError(t, g.bus.poke(gbAddrHDMA5, 0x00))
+
+	hdma5, err := g.bus.read(gbAddrHDMA5)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0x81), hdma5)
+
+	runFrames(t, g, 1)
+	val, err := g.bus.read(0x8100)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0x00), val)
+}
@@ -0,0 +1,22 @@
+package gb
+
+const (
+	gbAddrIF uint32 = 0xFF0F // interrupt flags
+
+	gbInterruptMask uint8 = 0x1F // 0b00011111
+)
+
+// pendingInterrupts returns the interrupts that are both requested and
+// enabled.
+func pendingInterrupts(r ram) (uint8, error) {
+	flags, err := r.read(gbAddrIF)
+	if err != nil {
+		return 0, err
+	}
+	enabled, err := r.read(gbAddrIE)
+	if err != nil {
+		return 0, err
+	}
+
+	return flags & enabled & gbInterruptMask, nil
+}
@@ -0,0 +1,121 @@
+package gb
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// Bits of the buttons argument to SetInputState.
+const (
+	ButtonA      uint8 = 0x1 << 0
+	ButtonB      uint8 = 0x1 << 1
+	ButtonSelect uint8 = 0x1 << 2
+	ButtonStart  uint8 = 0x1 << 3
+)
+
+// Bits of the directions argument to SetInputState.
+const (
+	DirectionRight uint8 = 0x1 << 0
+	DirectionLeft  uint8 = 0x1 << 1
+	DirectionUp    uint8 = 0x1 << 2
+	DirectionDown  uint8 = 0x1 << 3
+)
+
+const (
+	gbAddrP1 uint32 = 0xFF00 // joypad register
+
+	gbP1SelectDirections uint8 = 0x1 << 4 // active low
+	gbP1SelectButtons    uint8 = 0x1 << 5 // active low
+	gbP1SelectMask       uint8 = gbP1SelectDirections | gbP1SelectButtons
+	gbP1InputMask        uint8 = 0xF // 0b00001111
+)
+
+var (
+	gbErrJoypadAddress = errors.New("gbJoypad: address isn't the joypad register")
+)
+
+// gbJoypad backs the P1 register. The game selects a row of inputs by writing
+// to bits 4 and 5, then reads the pressed state of that row from the low
+// nibble, where 0 means pressed.
+type gbJoypad struct {
+	inputs     [gbSGBMaxPlayers]uint32 // buttons<<4 | directions, accessed atomically
+	selectBits uint8
+
+	sgb *sgbController // nil unless the model is a super gameboy
+}
+
+func newGBJoypad() *gbJoypad {
+	return &gbJoypad{
+		selectBits: gbP1SelectMask,
+	}
+}
+
+// setInput records the given player's currently pressed inputs. It's safe to
+// call from any goroutine.
+func (j *gbJoypad) setInput(player int, buttons, directions uint8) {
+	v := uint32(buttons&gbP1InputMask)<<4 | uint32(directions&gbP1InputMask)
+	atomic.StoreUint32(&j.inputs[player], v)
+}
+
+func (j *gbJoypad) poke(addr uint32, val uint8) error {
+	if addr != gbAddrP1 {
+		return gbErrJoypadAddress
+	}
+
+	j.selectBits = val & gbP1SelectMask
+	if j.sgb != nil {
+		j.sgb.write(j.selectBits)
+	}
+
+	return nil
+}
+
+func (j *gbJoypad) read(addr uint32) (uint8, error) {
+	if addr != gbAddrP1 {
+		return 0, gbErrJoypadAddress
+	}
+
+	player := 0
+	if j.sgb != nil {
+		player = j.sgb.player
+	}
+
+	input := atomic.LoadUint32(&j.inputs[player])
+	var pressed uint8
+	if j.selectBits == gbP1SelectMask {
+		// Player 1 reads as 0xF, player 2 as 0xE and so on.
+		pressed = uint8(player)
+	}
+	if j.selectBits&gbP1SelectDirections == 0 {
+		pressed |= uint8(input) & gbP1InputMask
+	}
+	if j.selectBits&gbP1SelectButtons == 0 {
+		pressed |= uint8(input>>4) & gbP1InputMask
+	}
+
+	// The unused top bits always read as 1.
+	return 0xC0 | j.selectBits | (^pressed & gbP1InputMask), nil
+}
+
+func (j *gbJoypad) ReadSlice(addr, n uint32) ([]uint8, error) {
+	return readN(j, addr, n)
+}
+
+// SetInputState sets which buttons and directions are held down, using the
+// Button* and Direction* bits. It's safe to call from a different goroutine
+// to the emulation loop, and takes effect on the next read of the joypad
+// register.
+func (g *Gameboy) SetInputState(buttons, directions uint8) {
+	g.joypad.setInput(0, buttons, directions)
+}
+
+// SetPlayerInputState sets the inputs for one of the players of a super
+// gameboy multiplayer game, from 0 to 3, like SetInputState does for the
+// first.
+func (g *Gameboy) SetPlayerInputState(player int, buttons, directions uint8) {
+	if player < 0 || player >= gbSGBMaxPlayers {
+		return
+	}
+
+	g.joypad.setInput(player, buttons, directions)
+}
@@ -0,0 +1,44 @@
+package gb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestJoypadDirections tests reading the directions row of the joypad.
+func TestJoypadDirections(t *testing.T) {
+	g := NewGameboy()
+	g.SetInputState(ButtonA, DirectionUp|DirectionLeft)
+
+	// Select the directions row by pulling bit 4 low.
+	assert.NoError(t, g.bus.poke(gbAddrP1, 0x20))
+	p1, err := g.bus.read(gbAddrP1)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, uint8(0xE9), p1) // 0b11101001
+
+	// Input changes take effect on the next read.
+	g.SetInputState(0, DirectionDown)
+	p1, err = g.bus.read(gbAddrP1)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0xE7), p1) // 0b11100111
+}
+
+// TestJoypadButtons tests reading the buttons row of the joypad.
+func TestJoypadButtons(t *testing.T) {
+	g := NewGameboy()
+	g.SetInputState(ButtonA|ButtonStart, DirectionRight)
+
+	assert.NoError(t, g.bus.poke(gbAddrP1, 0x10))
+	p1, err := g.bus.read(gbAddrP1)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0xD6), p1) // 0b11010110
+
+	// With neither row selected, nothing reads as pressed.
+	assert.NoError(t, g.bus.poke(gbAddrP1, 0x30))
+	p1, err = g.bus.read(gbAddrP1)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0xFF), p1)
+}
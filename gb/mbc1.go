@@ -0,0 +1,193 @@
+package gb
+
+const (
+	gbMBC1RAMEnableEnd uint32 = 0x1FFF
+	gbMBC1Bank1End     uint32 = 0x3FFF
+	gbMBC1Bank2End     uint32 = 0x5FFF
+
+	gbMBC1Bank1Mask uint8 = 0x1F // 0b00011111
+	gbMBC1Bank2Mask uint8 = 0x3  // 0b00000011
+	gbMBC1ModeMask  uint8 = 0x1  // 0b00000001
+	gbMBC1RAMEnable uint8 = 0xA  // low nibble that enables RAM
+)
+
+// mbc1Cartridge is a cartridge with the MBC1 memory bank controller, which
+// switches up to 2Mb of ROM into 0x4000-0x7FFF. In banking mode 1, the upper
+// bank bits also switch up to 4 banks of RAM into 0xA000-0xBFFF, and large
+// ROMs into 0x0000-0x3FFF.
+type mbc1Cartridge struct {
+	rom     []uint8
+	ram     []uint8 // empty if the cartridge has no RAM
+	battery bool    // whether RAM is kept when the power is off
+
+	bank1 uint8 // lower 5 bits of the ROM bank
+	bank2 uint8 // upper 2 bits of the ROM bank, or the RAM bank in mode 1
+	mode  uint8 // banking mode
+
+	ramEnabled bool
+
+	romSwitches, ramSwitches int
+}
+
+func newMBC1Cartridge(rom []uint8, ramSize int, battery bool) *mbc1Cartridge {
+	return &mbc1Cartridge{
+		rom:     rom,
+		ram:     make([]uint8, ramSize),
+		battery: battery,
+		bank1:   1,
+	}
+}
+
+// validateMBC1BankNumber corrects a ROM bank number for mapping to
+// 0x4000-0x7FFF. The MBC1 treats zero in the lower 5 bits as one, so banks
+// 0x00, 0x20, 0x40 and 0x60 can never be mapped there.
+func validateMBC1BankNumber(bank uint8) uint8 {
+	if bank&gbMBC1Bank1Mask == 0 {
+		bank |= 0x1
+	}
+
+	return bank
+}
+
+// romBank returns the ROM bank mapped to 0x4000-0x7FFF.
+func (c *mbc1Cartridge) romBank() int {
+	bank := validateMBC1BankNumber(c.bank2<<5 | c.bank1)
+	return int(bank) % (len(c.rom) / gbROMBankSize)
+}
+
+// ramBank returns the external RAM bank mapped to 0xA000-0xBFFF, which is
+// always bank 0 in mode 0.
+func (c *mbc1Cartridge) ramBank() int {
+	banks := len(c.ram) / gbExtRAMBankSize
+	if c.mode == 0 || banks < 2 {
+		return 0
+	}
+
+	return int(c.bank2) % banks
+}
+
+func (c *mbc1Cartridge) CurrentROMBank() int {
+	return c.romBank()
+}
+
+// BankSwitchCount returns the number of times a write has changed the ROM
+// bank, and RAMBankSwitchCount the number of times one has changed the RAM
+// bank.
+func (c *mbc1Cartridge) BankSwitchCount() int {
+	return c.romSwitches
+}
+
+func (c *mbc1Cartridge) RAMBankSwitchCount() int {
+	return c.ramSwitches
+}
+
+// romOffset returns the offset into the ROM of the given ROM address.
+func (c *mbc1Cartridge) romOffset(addr uint32) uint32 {
+	if addr < gbAddrROMBankN && c.mode == 0 {
+		return addr
+	}
+	if addr < gbAddrROMBankN {
+		bank := int(c.bank2<<5) % (len(c.rom) / gbROMBankSize)
+		return uint32(bank)*gbROMBankSize + addr
+	}
+
+	return uint32(c.romBank())*gbROMBankSize + addr - gbAddrROMBankN
+}
+
+func (c *mbc1Cartridge) poke(addr uint32, val uint8) error {
+	switch {
+	case addr <= gbMBC1RAMEnableEnd:
+		c.ramEnabled = val&0xF == gbMBC1RAMEnable
+
+	case addr <= gbMBC1Bank1End:
+		c.setBanks(val&gbMBC1Bank1Mask, c.bank2, c.mode)
+
+	case addr <= gbMBC1Bank2End:
+		c.setBanks(c.bank1, val&gbMBC1Bank2Mask, c.mode)
+
+	case addr <= gbAddrROMEnd:
+		c.setBanks(c.bank1, c.bank2, val&gbMBC1ModeMask)
+
+	case addr >= gbAddrExtRAM && addr <= gbAddrExtRAMEnd:
+		if i := c.ramOffset(addr); c.ramEnabled && int(i) < len(c.ram) {
+			c.ram[i] = val
+		}
+
+	default:
+		return gbErrCartridgeAddress
+	}
+
+	return nil
+}
+
+// ramOffset returns the offset into external RAM of the given RAM address.
+func (c *mbc1Cartridge) ramOffset(addr uint32) uint32 {
+	return uint32(c.ramBank())*gbExtRAMBankSize + addr - gbAddrExtRAM
+}
+
+// setBanks updates the bank registers and banking mode, counting any switches
+// they cause.
+func (c *mbc1Cartridge) setBanks(bank1, bank2, mode uint8) {
+	romBank, ramBank := c.romBank(), c.ramBank()
+	c.bank1, c.bank2, c.mode = bank1, bank2, mode
+
+	if c.romBank() != romBank {
+		c.romSwitches++
+	}
+	if c.ramBank() != ramBank {
+		c.ramSwitches++
+	}
+}
+
+func (c *mbc1Cartridge) read(addr uint32) (uint8, error) {
+	switch {
+	case addr <= gbAddrROMEnd:
+		return c.rom[c.romOffset(addr)], nil
+
+	case addr >= gbAddrExtRAM && addr <= gbAddrExtRAMEnd:
+		if i := c.ramOffset(addr); c.ramEnabled && int(i) < len(c.ram) {
+			return c.ram[i], nil
+		}
+		return 0xFF, nil
+	}
+
+	return 0, gbErrCartridgeAddress
+}
+
+func (c *mbc1Cartridge) ReadSlice(addr, n uint32) ([]uint8, error) {
+	if addr+n-1 <= gbAddrROMEnd && (addr < gbAddrROMBankN) == (addr+n-1 < gbAddrROMBankN) {
+		if s, ok := romSlice(c.rom, c.romOffset(addr), n); ok {
+			return s, nil
+		}
+	}
+
+	return readN(c, addr, n)
+}
+
+func (c *mbc1Cartridge) reset() {
+	c.bank1, c.bank2, c.mode = 1, 0, 0
+	c.ramEnabled = false
+}
+
+// snapshot returns the bank registers, banking mode and RAM enable flag
+// followed by external RAM.
+func (c *mbc1Cartridge) snapshot() []uint8 {
+	enabled := uint8(0)
+	if c.ramEnabled {
+		enabled = 1
+	}
+
+	return append([]uint8{c.bank1, c.bank2, c.mode, enabled}, c.ram...)
+}
+
+func (c *mbc1Cartridge) restore(snap []uint8) error {
+	if len(snap) != 4+len(c.ram) {
+		return gbErrSnapshotSize
+	}
+
+	c.bank1, c.bank2, c.mode = snap[0], snap[1], snap[2]
+	c.ramEnabled = snap[3] != 0
+	copy(c.ram, snap[4:])
+
+	return nil
+}
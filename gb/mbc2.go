@@ -0,0 +1,135 @@
+package gb
+
+const (
+	gbMBC2RegisterEnd uint32 = 0x3FFF
+	gbMBC2RAMSize            = 0x200 // 512 4-bit values
+
+	gbMBC2BankSelect uint32 = 0x0100 // address bit picking the register
+	gbMBC2BankMask   uint8  = 0xF    // 0b00001111
+	gbMBC2RAMEnable  uint8  = 0xA    // low nibble that enables RAM
+)
+
+// mbc2Cartridge is a cartridge with the MBC2 memory bank controller, which
+// switches up to 256Kb of ROM into 0x4000-0x7FFF and has 512 4-bit values of
+// RAM built in. The RAM is mirrored throughout 0xA000-0xBFFF.
+type mbc2Cartridge struct {
+	rom []uint8
+	ram [gbMBC2RAMSize]uint8
+
+	bank       uint8
+	ramEnabled bool
+
+	romSwitches int
+}
+
+func newMBC2Cartridge(rom []uint8) *mbc2Cartridge {
+	return &mbc2Cartridge{
+		rom:  rom,
+		bank: 1,
+	}
+}
+
+// romBank returns the ROM bank mapped to 0x4000-0x7FFF.
+func (c *mbc2Cartridge) romBank() int {
+	bank := c.bank
+	if bank == 0 {
+		bank = 1 // bank 0 can't be mapped twice
+	}
+
+	return int(bank) % (len(c.rom) / gbROMBankSize)
+}
+
+func (c *mbc2Cartridge) CurrentROMBank() int {
+	return c.romBank()
+}
+
+func (c *mbc2Cartridge) BankSwitchCount() int {
+	return c.romSwitches
+}
+
+// romOffset returns the offset into the ROM of the given ROM address.
+func (c *mbc2Cartridge) romOffset(addr uint32) uint32 {
+	if addr < gbAddrROMBankN {
+		return addr
+	}
+
+	return uint32(c.romBank())*gbROMBankSize + addr - gbAddrROMBankN
+}
+
+func (c *mbc2Cartridge) poke(addr uint32, val uint8) error {
+	switch {
+	case addr <= gbMBC2RegisterEnd && addr&gbMBC2BankSelect == 0:
+		c.ramEnabled = val&0xF == gbMBC2RAMEnable
+
+	case addr <= gbMBC2RegisterEnd:
+		romBank := c.romBank()
+		c.bank = val & gbMBC2BankMask
+		if c.romBank() != romBank {
+			c.romSwitches++
+		}
+
+	case addr <= gbAddrROMEnd:
+		// Writes to ROM are ignored.
+
+	case addr >= gbAddrExtRAM && addr <= gbAddrExtRAMEnd:
+		if c.ramEnabled {
+			c.ram[(addr-gbAddrExtRAM)%gbMBC2RAMSize] = val & 0xF
+		}
+
+	default:
+		return gbErrCartridgeAddress
+	}
+
+	return nil
+}
+
+// read returns RAM values with the undefined upper nibble set.
+func (c *mbc2Cartridge) read(addr uint32) (uint8, error) {
+	switch {
+	case addr <= gbAddrROMEnd:
+		return c.rom[c.romOffset(addr)], nil
+
+	case addr >= gbAddrExtRAM && addr <= gbAddrExtRAMEnd:
+		if !c.ramEnabled {
+			return 0xFF, nil
+		}
+		return 0xF0 | c.ram[(addr-gbAddrExtRAM)%gbMBC2RAMSize], nil
+	}
+
+	return 0, gbErrCartridgeAddress
+}
+
+func (c *mbc2Cartridge) ReadSlice(addr, n uint32) ([]uint8, error) {
+	if addr+n-1 <= gbAddrROMEnd && (addr < gbAddrROMBankN) == (addr+n-1 < gbAddrROMBankN) {
+		if s, ok := romSlice(c.rom, c.romOffset(addr), n); ok {
+			return s, nil
+		}
+	}
+
+	return readN(c, addr, n)
+}
+
+func (c *mbc2Cartridge) reset() {
+	c.bank, c.ramEnabled = 1, false
+}
+
+// snapshot returns the bank register and RAM enable flag followed by RAM.
+func (c *mbc2Cartridge) snapshot() []uint8 {
+	enabled := uint8(0)
+	if c.ramEnabled {
+		enabled = 1
+	}
+
+	return append([]uint8{c.bank, enabled}, c.ram[:]...)
+}
+
+func (c *mbc2Cartridge) restore(snap []uint8) error {
+	if len(snap) != 2+len(c.ram) {
+		return gbErrSnapshotSize
+	}
+
+	c.bank, c.ramEnabled = snap[0], snap[1] != 0
+	copy(c.ram[:], snap[2:])
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package gb
+
+// GameboyModel identifies a particular revision of the gameboy hardware. The
+// models differ in their boot register state, amount of work RAM and support
+// for colour-only features.
+type GameboyModel int
+
+const (
+	DMG GameboyModel = 0 // original gameboy
+	MGB GameboyModel = 1 // gameboy pocket
+	SGB GameboyModel = 2 // super gameboy
+	CGB GameboyModel = 3 // gameboy color
+)
+
+// gbBootRegisters holds the register values left behind by a model's boot ROM
+// when it hands control over to the cartridge.
+type gbBootRegisters struct {
+	af, bc, de, hl, sp, pc uint16
+}
+
+func (m GameboyModel) bootRegisters() gbBootRegisters {
+	switch m {
+	case MGB:
+		return gbBootRegisters{0xFFB0, 0x0013, 0x00D8, 0x014D, 0xFFFE, 0x0100}
+
+	case SGB:
+		return gbBootRegisters{0x0100, 0x0014, 0x0000, 0xC060, 0xFFFE, 0x0100}
+
+	case CGB:
+		return gbBootRegisters{0x1180, 0x0000, 0xFF56, 0x000D, 0xFFFE, 0x0100}
+	}
+
+	return gbBootRegisters{0x01B0, 0x0013, 0x00D8, 0x014D, 0xFFFE, 0x0100}
+}
+
+// isCGB returns true if the model supports gameboy color features, such as
+// banked work RAM and the double-speed switch.
+func (m GameboyModel) isCGB() bool {
+	return m == CGB
+}
+
+// wramBanks returns the number of 4Kb work RAM banks available to the model.
+func (m GameboyModel) wramBanks() int {
+	if m.isCGB() {
+		return 8
+	}
+
+	return 2
+}
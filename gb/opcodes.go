@@ -1,14 +1,17 @@
 package gb
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 type gbOpcodeType int
 
 const (
 	gbOpcodeHeader00 uint8 = 0x00
 	gbOpcodeHeader01 uint8 = 0x01
-	gbOpcodeHeader10 uint8 = 0x10
-	gbOpcodeHeader11 uint8 = 0x11
+	gbOpcodeHeader10 uint8 = 0x2
+	gbOpcodeHeader11 uint8 = 0x3
 
 	gbOpcodePart000 uint8 = 0x0
 	gbOpcodePart001 uint8 = 0x1
@@ -43,13 +46,150 @@ const (
 	gbOpcodeLDHlIA gbOpcodeType = 17 // [ LD (HLI), A ]
 	gbOpcodeLDAHlD gbOpcodeType = 18 // [ LD A, (HLD) ]
 	gbOpcodeLDHlDA gbOpcodeType = 19 // [ LD (HLD), A ]
+
+	// CPU control instructions
+	gbOpcodeNOP  gbOpcodeType = 20 // [ NOP ]
+	gbOpcodeSTOP gbOpcodeType = 24 // [ STOP ]
+	gbOpcodeHALT gbOpcodeType = 25 // [ HALT ]
+	gbOpcodeDI   gbOpcodeType = 26 // [ DI ]
+	gbOpcodeEI   gbOpcodeType = 27 // [ EI ]
+
+	// Jump instructions
+	gbOpcodeJRN   gbOpcodeType = 21 // [ JR n ]
+	gbOpcodeJRCcN gbOpcodeType = 22 // [ JR cc, n ]
+	gbOpcodeJPNn  gbOpcodeType = 23 // [ JP nn ]
+	gbOpcodeCALL  gbOpcodeType = 29 // [ CALL nn ]
+
+	// Stack instructions
+	gbOpcodePUSH gbOpcodeType = 28 // [ PUSH qq ]
 )
 
+// gbOpcodeCycles holds the number of cycles each opcode takes, in units of 4
+// quartz cycles, as listed in the Pan Docs. Conditional opcodes take the first
+// count if their condition holds and the second otherwise.
+var gbOpcodeCycles = map[gbOpcodeType][2]int{
+	gbOpcodeLDRRp: {1, 1},
+	gbOpcodeLDRHl: {2, 2},
+	gbOpcodeLDHlR: {2, 2},
+	gbOpcodeLDRN:  {2, 2},
+	gbOpcodeLDHlN: {3, 3},
+	gbOpcodeNOP:   {1, 1},
+	gbOpcodeSTOP:  {1, 1},
+	gbOpcodeHALT:  {1, 1},
+	gbOpcodeDI:    {1, 1},
+	gbOpcodeEI:    {1, 1},
+	gbOpcodeJRN:   {3, 3},
+	gbOpcodeJRCcN: {3, 2},
+	gbOpcodeJPNn:  {4, 4},
+	gbOpcodeCALL:  {6, 6},
+	gbOpcodePUSH:  {4, 4},
+}
+
 var (
-	gbErrInvalidOpcode   = errors.New("gbOpcode: data isn't a valid opcode")
-	gbErrWrongOpcodeSize = errors.New("gbOpcode: wrong amount of data given for opcode")
+	gbErrInvalidOpcode  = errors.New("gbOpcode: data isn't a valid opcode")
+	gbErrOpcodeType     = errors.New("gbOpcode: opcode has no type")
+	gbErrOpcodeCycles   = errors.New("gbOpcode: cycles don't match the opcode's type")
+	gbErrOpcodeEncoding = errors.New("gbOpcode: fields don't encode the opcode's type")
 )
 
+// gbIllegalOpcodes are the bytes that don't encode an opcode at all. Real
+// hardware locks up if it tries to execute one.
+var gbIllegalOpcodes = []uint8{
+	0xD3, 0xDB, 0xDD, 0xE3, 0xE4, 0xEB, 0xEC, 0xED, 0xF4, 0xFC, 0xFD,
+}
+
+// UnknownOpcodeError is returned by decode for opcodes that exist on the
+// hardware but haven't been implemented yet, as opposed to gbErrInvalidOpcode
+// for data that isn't an opcode at all. Byte is the opcode's first byte, or
+// its second if it's 0xCB-prefixed.
+type UnknownOpcodeError struct {
+	Byte uint8
+	CB   bool
+}
+
+func (e *UnknownOpcodeError) Error() string {
+	if e.CB {
+		return fmt.Sprintf("gbOpcode: opcode 0xCB 0x%02X isn't implemented", e.Byte)
+	}
+	return fmt.Sprintf("gbOpcode: opcode 0x%02X isn't implemented", e.Byte)
+}
+
+// WrongOpcodeSizeError is returned by decode when it's given the wrong amount
+// of data for an opcode. Missing is the number of bytes needed to fully decode
+// it, or negative in the number of extra bytes if too much data was given.
+type WrongOpcodeSizeError struct {
+	Missing int
+}
+
+func (e *WrongOpcodeSizeError) Error() string {
+	return fmt.Sprintf("gbOpcode: wrong amount of data given for opcode (missing %d bytes)", e.Missing)
+}
+
+func (ot gbOpcodeType) String() string {
+	switch ot {
+	case gbOpcodeLDRRp:
+		return "LD_R_R"
+	case gbOpcodeLDRHl:
+		return "LD_R_HL"
+	case gbOpcodeLDHlR:
+		return "LD_HL_R"
+	case gbOpcodeLDRN:
+		return "LD_R_N"
+	case gbOpcodeLDHlN:
+		return "LD_HL_N"
+	case gbOpcodeLDABc:
+		return "LD_A_BC"
+	case gbOpcodeLDBcA:
+		return "LD_BC_A"
+	case gbOpcodeLDADe:
+		return "LD_A_DE"
+	case gbOpcodeLDDeA:
+		return "LD_DE_A"
+	case gbOpcodeLDAC:
+		return "LD_A_C"
+	case gbOpcodeLDCA:
+		return "LD_C_A"
+	case gbOpcodeLDAN:
+		return "LD_A_N"
+	case gbOpcodeLDNA:
+		return "LD_N_A"
+	case gbOpcodeLDANn:
+		return "LD_A_NN"
+	case gbOpcodeLDNnA:
+		return "LD_NN_A"
+	case gbOpcodeLDAHlI:
+		return "LD_A_HLI"
+	case gbOpcodeLDHlIA:
+		return "LD_HLI_A"
+	case gbOpcodeLDAHlD:
+		return "LD_A_HLD"
+	case gbOpcodeLDHlDA:
+		return "LD_HLD_A"
+	case gbOpcodeNOP:
+		return "NOP"
+	case gbOpcodeSTOP:
+		return "STOP"
+	case gbOpcodeHALT:
+		return "HALT"
+	case gbOpcodeDI:
+		return "DI"
+	case gbOpcodeEI:
+		return "EI"
+	case gbOpcodeJRN:
+		return "JR_N"
+	case gbOpcodeJRCcN:
+		return "JR_CC_N"
+	case gbOpcodeJPNn:
+		return "JP_NN"
+	case gbOpcodeCALL:
+		return "CALL_NN"
+	case gbOpcodePUSH:
+		return "PUSH_QQ"
+	}
+
+	return "UNKNOWN"
+}
+
 type gbOpcode struct {
 	header uint8   // bits 7,6 of opcode
 	first  uint8   // bits 5,4,3 of opcode
@@ -60,75 +200,212 @@ type gbOpcode struct {
 	cycles int // cycles measures in units of 4 quartz cycles
 }
 
-// decode attempts to decode the given data into an opcode. Some opcodes are
-// larger in size than others - if there isn't enough data to fully decode one,
-// the returned integer provides the number of missing bytes. Similarly, if
-// there is too much data, the returned integer is negative in the number of
-// additional bytes provided.
-// TODO(guy): Handle this with an explicit error type instead.
-func decode(ops []uint8) (*gbOpcode, int, error) {
-	if len(ops) == 0 {
-		return nil, 0, gbErrInvalidOpcode
-	}
+// setType sets the opcode's type and its cycle count. Conditional opcodes are
+// given the count for when their condition doesn't hold.
+func (o *gbOpcode) setType(t gbOpcodeType) {
+	o.tipe = t
+	o.cycles = gbOpcodeCycles[t][1]
+}
+
+// decodeFunc decodes the opcode whose first byte selected it from a dispatch
+// table. It follows the same contract as decode.
+type decodeFunc func(ops []uint8) (*gbOpcode, error)
 
-	o := gbOpcode{
-		header: (ops[0] & gbOpcodeMaskHeader) >> 6,
-		first:  (ops[0] & gbOpcodeMaskFirst) >> 3,
-		second: (ops[0] & gbOpcodeMaskSecond),
-		data:   ops[1:],
+var (
+	// gbDecodeTable dispatches on the first byte of an opcode, and
+	// gbDecodeTableCB on the second byte of 0xCB-prefixed opcodes.
+	gbDecodeTable   [256]decodeFunc
+	gbDecodeTableCB [256]decodeFunc
+)
+
+const gbOpcodePrefixCB uint8 = 0xCB
+
+func init() {
+	for b := range gbDecodeTable {
+		gbDecodeTable[b] = decodeUnknown
+		gbDecodeTableCB[b] = decodeUnknownCB
 	}
+	for _, b := range gbIllegalOpcodes {
+		gbDecodeTable[b] = decodeInvalid
+	}
+	gbDecodeTable[gbOpcodePrefixCB] = decodePrefixCB
 
-	switch o.header {
-	case gbOpcodeHeader01:
-		fR := decodeRegisterType(o.first)
-		sR := decodeRegisterType(o.second)
+	for b := 0; b < 256; b++ {
+		op := uint8(b)
+		header := (op & gbOpcodeMaskHeader) >> 6
+		first := (op & gbOpcodeMaskFirst) >> 3
+		second := op & gbOpcodeMaskSecond
+		fR := decodeRegisterType(first)
+		sR := decodeRegisterType(second)
 
-		// The 01 opcodes are all a single byte.
-		if len(o.data) > 0 {
-			return nil, -len(o.data), gbErrWrongOpcodeSize
-		}
+		switch header {
+		case gbOpcodeHeader01:
+			switch {
+			// 0b01110110 would be [LD (HL), (HL)], but it's HALT instead.
+			case fR == gbRegisterHLIndirect && sR == gbRegisterHLIndirect:
+				gbDecodeTable[op] = decodeFixed(gbOpcodeHALT, 1)
 
-		if o.first == gbOpcodePart110 {
-			o.tipe = gbOpcodeLDHlR
-			o.cycles = 2
-			return &o, 0, nil
-		}
+			case fR == gbRegisterHLIndirect:
+				gbDecodeTable[op] = decodeFixed(gbOpcodeLDHlR, 1)
 
-		if o.second == gbOpcodePart110 {
-			o.tipe = gbOpcodeLDRHl
-			o.cycles = 2
-			return &o, 0, nil
-		}
+			case sR == gbRegisterHLIndirect:
+				gbDecodeTable[op] = decodeFixed(gbOpcodeLDRHl, 1)
 
-		if fR != gbRegisterUnknown && sR != gbRegisterUnknown {
-			o.tipe = gbOpcodeLDRRp
-			o.cycles = 1
-			return &o, 0, nil
-		}
+			default:
+				gbDecodeTable[op] = decodeFixed(gbOpcodeLDRRp, 1)
+			}
+
+		case gbOpcodeHeader00:
+			switch {
+			case first == gbOpcodePart000 && second == gbOpcodePart000:
+				gbDecodeTable[op] = decodeFixed(gbOpcodeNOP, 1)
 
-	case gbOpcodeHeader00:
-		fR := decodeRegisterType(o.first)
+			// STOP is followed by a padding byte, which is skipped.
+			case first == gbOpcodePart010 && second == gbOpcodePart000:
+				gbDecodeTable[op] = decodeFixed(gbOpcodeSTOP, 2)
 
-		if fR != gbRegisterUnknown && o.second == gbOpcodePart110 {
-			if len(o.data) != 1 {
-				return nil, 1 - len(o.data), gbErrWrongOpcodeSize
+			case first == gbOpcodePart011 && second == gbOpcodePart000:
+				gbDecodeTable[op] = decodeFixed(gbOpcodeJRN, 2)
+
+			case first&0x4 != 0 && second == gbOpcodePart000:
+				gbDecodeTable[op] = decodeFixed(gbOpcodeJRCcN, 2)
+
+			case fR == gbRegisterHLIndirect && second == gbOpcodePart110:
+				gbDecodeTable[op] = decodeFixed(gbOpcodeLDHlN, 2)
+
+			case second == gbOpcodePart110:
+				gbDecodeTable[op] = decodeFixed(gbOpcodeLDRN, 2)
 			}
 
-			o.tipe = gbOpcodeLDRN
-			o.cycles = 2
-			return &o, 0, nil
-		}
+		case gbOpcodeHeader11:
+			switch {
+			case first == gbOpcodePart000 && second == gbOpcodePart011:
+				gbDecodeTable[op] = decodeFixed(gbOpcodeJPNn, 3)
+
+			case first == gbOpcodePart110 && second == gbOpcodePart011:
+				gbDecodeTable[op] = decodeFixed(gbOpcodeDI, 1)
 
-		if o.first == gbOpcodePart110 && o.second == gbOpcodePart110 {
-			if len(o.data) != 1 {
-				return nil, 1 - len(o.data), gbErrWrongOpcodeSize
+			case first == gbOpcodePart111 && second == gbOpcodePart011:
+				gbDecodeTable[op] = decodeFixed(gbOpcodeEI, 1)
+
+			case first == gbOpcodePart001 && second == gbOpcodePart101:
+				gbDecodeTable[op] = decodeFixed(gbOpcodeCALL, 3)
+
+			case first&0x1 == 0 && second == gbOpcodePart101:
+				gbDecodeTable[op] = decodeFixed(gbOpcodePUSH, 1)
 			}
+		}
+	}
+}
+
+// decode attempts to decode the given data into an opcode. Some opcodes are
+// larger in size than others - if there isn't exactly enough data to decode
+// one, a *WrongOpcodeSizeError is returned.
+func decode(ops []uint8) (*gbOpcode, error) {
+	if len(ops) == 0 {
+		return nil, gbErrInvalidOpcode
+	}
+
+	return gbDecodeTable[ops[0]](ops)
+}
 
-			o.tipe = gbOpcodeLDHlN
-			o.cycles = 3
-			return &o, 0, nil
+// decodeFixed returns a decodeFunc for opcodes of the given type, which are
+// always size bytes long.
+func decodeFixed(t gbOpcodeType, size int) decodeFunc {
+	return func(ops []uint8) (*gbOpcode, error) {
+		if len(ops) != size {
+			return nil, &WrongOpcodeSizeError{Missing: size - len(ops)}
 		}
+
+		o := &gbOpcode{
+			header: (ops[0] & gbOpcodeMaskHeader) >> 6,
+			first:  (ops[0] & gbOpcodeMaskFirst) >> 3,
+			second: (ops[0] & gbOpcodeMaskSecond),
+			data:   append([]uint8(nil), ops[1:]...), // don't retain the caller's buffer
+		}
+		o.setType(t)
+
+		return o, nil
+	}
+}
+
+func decodeInvalid(ops []uint8) (*gbOpcode, error) {
+	return nil, gbErrInvalidOpcode
+}
+
+// decodeUnknown reports opcodes that haven't been implemented yet, so that
+// they aren't mistaken for invalid data.
+func decodeUnknown(ops []uint8) (*gbOpcode, error) {
+	return nil, &UnknownOpcodeError{Byte: ops[0]}
+}
+
+func decodeUnknownCB(ops []uint8) (*gbOpcode, error) {
+	return nil, &UnknownOpcodeError{Byte: ops[1], CB: true}
+}
+
+// decodePrefixCB dispatches 0xCB-prefixed opcodes on their second byte.
+func decodePrefixCB(ops []uint8) (*gbOpcode, error) {
+	if len(ops) < 2 {
+		return nil, &WrongOpcodeSizeError{Missing: 2 - len(ops)}
+	}
+
+	return gbDecodeTableCB[ops[1]](ops)
+}
+
+// validateOpcode checks that the opcode's fields are consistent with each
+// other, by encoding it again and checking that it decodes to the same type.
+// This catches decode bugs that would otherwise surface as misbehaving
+// instructions.
+func validateOpcode(o *gbOpcode) error {
+	cycles, ok := gbOpcodeCycles[o.tipe]
+	if !ok {
+		return gbErrOpcodeType
+	}
+	if o.cycles <= 0 || (o.cycles != cycles[0] && o.cycles != cycles[1]) {
+		return gbErrOpcodeCycles
+	}
+	if o.header > 0x3 || o.first > 0x7 || o.second > 0x7 {
+		return gbErrOpcodeEncoding
+	}
+
+	ops := append([]uint8{o.header<<6 | o.first<<3 | o.second}, o.data...)
+	decoded, err := decode(ops)
+	if err != nil {
+		return err
+	}
+	if decoded.tipe != o.tipe {
+		return gbErrOpcodeEncoding
 	}
 
-	return nil, 0, gbErrInvalidOpcode
+	return nil
+}
+
+// String returns a human-readable form of the opcode for debugging, such as
+// "LD B, C (cycles=1, size=1)".
+func (o *gbOpcode) String() string {
+	mnemonic, ok := gbMnemonics[o.tipe]
+	if !ok {
+		mnemonic = "UNKNOWN"
+	}
+
+	// Without knowing its address, relative jumps show their raw offset.
+	operands := formatOperands(o, 0)
+	switch o.tipe {
+	case gbOpcodeJRN:
+		operands = fmt.Sprintf("%+d", int8(o.data[0]))
+
+	case gbOpcodeJRCcN:
+		operands = fmt.Sprintf("%s, %+d", gbConditionNames[o.first&0x3],
+			int8(o.data[0]))
+	}
+
+	if operands != "" {
+		mnemonic += " " + operands
+	}
+	return fmt.Sprintf("%s (cycles=%d, size=%d)", mnemonic, o.cycles, o.size())
+}
+
+// size returns the number of bytes the opcode occupies in memory.
+func (o *gbOpcode) size() int {
+	return 1 + len(o.data)
 }
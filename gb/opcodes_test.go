@@ -0,0 +1,238 @@
+package gb
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// decodeAll returns every opcode that decode accepts, with zeroed data bytes.
+func decodeAll() []*gbOpcode {
+	var res []*gbOpcode
+	for b := 0; b < 256; b++ {
+		op, err := decodeAt([]byte{uint8(b), 0x00, 0x00}, 0)
+		if err == nil {
+			res = append(res, op)
+		}
+	}
+
+	return res
+}
+
+// TestOpcodeString tests that every decodable opcode has a description.
+func TestOpcodeString(t *testing.T) {
+	ops := decodeAll()
+	assert.NotEmpty(t, ops)
+
+	for _, op := range ops {
+		assert.NotEmpty(t, op.String())
+		assert.NotContains(t, op.String(), "UNKNOWN")
+	}
+}
+
+// TestOpcodeStringFormat tests the format of opcode descriptions.
+func TestOpcodeStringFormat(t *testing.T) {
+	op, err := decodeAt([]byte{0x41}, 0)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "LD B, C (cycles=1, size=1)", op.String())
+	}
+
+	op, err = decodeAt([]byte{0x18, 0xFE}, 0)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "JR -2 (cycles=3, size=2)", op.String())
+	}
+
+	assert.Equal(t, "UNKNOWN (cycles=0, size=1)", (&gbOpcode{}).String())
+}
+
+// TestOpcodeTypeString tests the names of the opcode types.
+func TestOpcodeTypeString(t *testing.T) {
+	assert.Equal(t, "LD_R_R", gbOpcodeLDRRp.String())
+	assert.Equal(t, "JR_N", gbOpcodeJRN.String())
+	assert.Equal(t, "UNKNOWN", gbOpcodeType(0).String())
+
+	for _, op := range decodeAll() {
+		assert.NotEqual(t, "UNKNOWN", op.tipe.String())
+	}
+}
+
+// TestOpcodeCycles tests the cycle counts assigned by decode.
+func TestOpcodeCycles(t *testing.T) {
+	op, err := decodeAt([]byte{0xC3, 0x00, 0x02}, 0) // JP 0x200
+	if assert.NoError(t, err) {
+		assert.Equal(t, gbOpcodeJPNn, op.tipe)
+		assert.Equal(t, 4, op.cycles)
+	}
+
+	op, err = decodeAt([]byte{0x20, 0x05}, 0) // JR NZ, 5
+	if assert.NoError(t, err) {
+		assert.Equal(t, gbOpcodeJRCcN, op.tipe)
+		assert.Equal(t, 2, op.cycles)
+	}
+
+	for _, op := range decodeAll() {
+		assert.True(t, op.cycles > 0, op.String())
+	}
+}
+
+// TestValidateOpcode tests that every decoded opcode is consistent, and that
+// inconsistent ones are caught.
+func TestValidateOpcode(t *testing.T) {
+	for _, op := range decodeAll() {
+		assert.NoError(t, validateOpcode(op), op.String())
+	}
+
+	decodeJP := func() *gbOpcode {
+		op, err := decode([]uint8{0xC3, 0x00, 0x02}) // JP 0x200
+		assert.NoError(t, err)
+		return op
+	}
+
+	op := decodeJP()
+	op.tipe = 0
+	assert.Equal(t, gbErrOpcodeType, validateOpcode(op))
+
+	op = decodeJP()
+	op.cycles = 0
+	assert.Equal(t, gbErrOpcodeCycles, validateOpcode(op))
+
+	op = decodeJP()
+	op.data = op.data[:1]
+	var sizeErr *WrongOpcodeSizeError
+	assert.True(t, errors.As(validateOpcode(op), &sizeErr))
+
+	op = decodeJP()
+	op.tipe = gbOpcodeCALL
+	op.cycles = gbOpcodeCycles[gbOpcodeCALL][0]
+	assert.Equal(t, gbErrOpcodeEncoding, validateOpcode(op))
+
+	op = decodeJP()
+	op.second = 0x8
+	assert.Equal(t, gbErrOpcodeEncoding, validateOpcode(op))
+}
+
+// TestDecodeSizes tests that decode reports missing and extra bytes.
+func TestDecodeSizes(t *testing.T) {
+	tests := []struct {
+		ops     []uint8
+		missing int
+	}{
+		{[]uint8{0xC3}, 2},              // JP nn
+		{[]uint8{0xC3, 0x00}, 1},        // JP nn
+		{[]uint8{0x36}, 1},              // LD (HL), n
+		{[]uint8{0x41, 0x00}, -1},       // LD B, C
+		{[]uint8{0x00, 0x00, 0x00}, -2}, // NOP
+		{[]uint8{0xCB}, 1},              // CB prefix
+	}
+
+	for _, test := range tests {
+		_, err := decode(test.ops)
+
+		var sizeErr *WrongOpcodeSizeError
+		if assert.True(t, errors.As(err, &sizeErr), "%X", test.ops) {
+			assert.Equal(t, test.missing, sizeErr.Missing, "%X", test.ops)
+		}
+	}
+
+	_, err := decode([]uint8{0xD3})
+	assert.Equal(t, gbErrInvalidOpcode, err)
+}
+
+// TestDecodeUnknownOpcode tests that opcodes which haven't been implemented
+// are told apart from invalid data.
+func TestDecodeUnknownOpcode(t *testing.T) {
+	tests := []struct {
+		ops      []uint8
+		expected UnknownOpcodeError
+	}{
+		{[]uint8{0x27}, UnknownOpcodeError{Byte: 0x27}}, // DAA
+		{[]uint8{0x3F}, UnknownOpcodeError{Byte: 0x3F}}, // CCF
+		{[]uint8{0xCB, 0x37}, UnknownOpcodeError{Byte: 0x37, CB: true}},
+	}
+
+	for _, test := range tests {
+		_, err := decode(test.ops)
+
+		var unknownErr *UnknownOpcodeError
+		if assert.True(t, errors.As(err, &unknownErr), "%X", test.ops) {
+			assert.Equal(t, test.expected, *unknownErr)
+		}
+	}
+
+	// Illegal opcodes aren't waiting to be implemented.
+	for _, b := range gbIllegalOpcodes {
+		_, err := decode([]uint8{b})
+		assert.Equal(t, gbErrInvalidOpcode, err)
+	}
+}
+
+// FuzzDecode tests that decode copes with arbitrary data.
+func FuzzDecode(f *testing.F) {
+	for _, op := range decodeAll() {
+		b := op.header<<6 | op.first<<3 | op.second
+		f.Add(append([]byte{b}, op.data...))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		op, err := decode(data)
+		if err != nil {
+			assert.Nil(t, op)
+			return
+		}
+
+		// A decoded opcode must have used up all of the data.
+		if assert.NotNil(t, op) {
+			assert.Equal(t, len(data), op.size())
+			assert.Equal(t, data[0], op.header<<6|op.first<<3|op.second)
+		}
+	})
+}
+
+// BenchmarkDecodeOpcodes measures decoding opcodes that used to sit at
+// different depths of decode's switch statements.
+func BenchmarkDecodeOpcodes(b *testing.B) {
+	benchFn := func(ops []uint8) func(*testing.B) {
+		return func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				decode(ops)
+			}
+		}
+	}
+
+	b.Run("LD (HL),B", benchFn([]uint8{0x70}))
+	b.Run("LD B,C", benchFn([]uint8{0x41}))
+	b.Run("NOP", benchFn([]uint8{0x00}))
+	b.Run("LD (HL),n", benchFn([]uint8{0x36, 0x42}))
+	b.Run("JP nn", benchFn([]uint8{0xC3, 0x34, 0x12}))
+	b.Run("invalid", benchFn([]uint8{0xD3}))
+}
+
+// BenchmarkDecode measures decoding every one-byte opcode in turn. Bytes that
+// aren't one-byte opcodes fail to decode, which is part of the overhead being
+// measured.
+func BenchmarkDecode(b *testing.B) {
+	var table [256][]uint8
+	for i := range table {
+		table[i] = []uint8{uint8(i)}
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		decode(table[i%len(table)])
+	}
+}
+
+// BenchmarkDecodeThreeBytes measures decoding three-byte opcodes, which copy
+// their data into a new slice.
+func BenchmarkDecodeThreeBytes(b *testing.B) {
+	table := [][]uint8{
+		{0xC3, 0x34, 0x12}, // JP nn
+		{0xCD, 0x34, 0x12}, // CALL nn
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		decode(table[i%len(table)])
+	}
+}
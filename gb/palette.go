@@ -0,0 +1,100 @@
+package gb
+
+import "errors"
+
+const (
+	gbAddrBCPS uint32 = 0xFF68 // background palette spec (CGB only)
+	gbAddrBCPD uint32 = 0xFF69 // background palette data (CGB only)
+	gbAddrOCPS uint32 = 0xFF6A // object palette spec (CGB only)
+	gbAddrOCPD uint32 = 0xFF6B // object palette data (CGB only)
+
+	gbPaletteBytes      = 64   // 8 palettes of 4 colours, 2 bytes each
+	gbPaletteIndexMask  = 0x3F // 0b00111111
+	gbPaletteAutoInc    = 0x80 // 0b10000000
+	gbPaletteUnusedBits = 0x40 // 0b01000000
+)
+
+var (
+	gbErrPaletteAddress = errors.New("gbPaletteRAM: address isn't a palette register")
+)
+
+// gbPaletteRAM holds a set of CGB colour palettes, accessed through a pair of
+// registers. The spec register selects a byte of palette memory, and can be
+// set to advance to the next byte after every write to the data register.
+// Colours are stored little-endian in BGR555 format.
+type gbPaletteRAM struct {
+	specAddr, dataAddr uint32
+
+	data [gbPaletteBytes]uint8
+	spec uint8
+}
+
+func newGBPaletteRAM(specAddr, dataAddr uint32) *gbPaletteRAM {
+	return &gbPaletteRAM{
+		specAddr: specAddr,
+		dataAddr: dataAddr,
+	}
+}
+
+// color returns the BGR555 colour at the given index of the given palette.
+func (c *gbPaletteRAM) color(palette, index uint8) uint16 {
+	i := (palette&0x7)*8 + (index&0x3)*2
+	return uint16(c.data[i]) | uint16(c.data[i+1])<<8
+}
+
+func (c *gbPaletteRAM) poke(addr uint32, val uint8) error {
+	switch addr {
+	case c.specAddr:
+		c.spec = val &^ gbPaletteUnusedBits
+
+	case c.dataAddr:
+		c.data[c.spec&gbPaletteIndexMask] = val
+		if c.spec&gbPaletteAutoInc != 0 {
+			c.spec = gbPaletteAutoInc | (c.spec+1)&gbPaletteIndexMask
+		}
+
+	default:
+		return gbErrPaletteAddress
+	}
+
+	return nil
+}
+
+func (c *gbPaletteRAM) read(addr uint32) (uint8, error) {
+	switch addr {
+	case c.specAddr:
+		return gbPaletteUnusedBits | c.spec, nil
+
+	case c.dataAddr:
+		return c.data[c.spec&gbPaletteIndexMask], nil
+	}
+
+	return 0, gbErrPaletteAddress
+}
+
+func (c *gbPaletteRAM) ReadSlice(addr, n uint32) ([]uint8, error) {
+	return readN(c, addr, n)
+}
+
+// clear zeroes palette memory and the spec register.
+func (c *gbPaletteRAM) clear() {
+	c.data = [gbPaletteBytes]uint8{}
+	c.spec = 0
+}
+
+// snapshot returns a copy of palette memory, followed by the spec register.
+func (c *gbPaletteRAM) snapshot() []uint8 {
+	return append(append([]uint8(nil), c.data[:]...), c.spec)
+}
+
+// restore loads a snapshot taken by snapshot.
+func (c *gbPaletteRAM) restore(snap []uint8) error {
+	if len(snap) != gbPaletteBytes+1 {
+		return gbErrSnapshotSize
+	}
+
+	copy(c.data[:], snap)
+	c.spec = snap[gbPaletteBytes]
+
+	return nil
+}
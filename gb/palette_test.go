@@ -0,0 +1,98 @@
+package gb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestBackgroundPalettes tests writing a full set of background palettes
+// with auto-increment and reading them back.
+func TestBackgroundPalettes(t *testing.T) {
+	g := NewGameboy(WithModel(CGB))
+
+	assert.NoError(t, g.bus.poke(gbAddrBCPS, gbPaletteAutoInc))
+	for i := 0; i < gbPaletteBytes; i++ {
+		assert.NoError(t, g.bus.poke(gbAddrBCPD, uint8(i*3)))
+	}
+
+	// The index wraps around after the last byte.
+	bcps, err := g.bus.read(gbAddrBCPS)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0xC0), bcps)
+
+	for i := 0; i < gbPaletteBytes; i++ {
+		assert.NoError(t, g.bus.poke(gbAddrBCPS, uint8(i)))
+		val, err := g.bus.read(gbAddrBCPD)
+		assert.NoError(t, err)
+		assert.Equal(t, uint8(i*3), val)
+	}
+
+	// Without auto-increment, writes keep hitting the same byte.
+	assert.NoError(t, g.bus.poke(gbAddrBCPS, 0x02))
+	assert.NoError(t, g.bus.poke(gbAddrBCPD, 0xAA))
+	assert.NoError(t, g.bus.poke(gbAddrBCPD, 0xBB))
+	assert.Equal(t, uint8(0xBB), g.bgPalettes.data[2])
+	assert.Equal(t, uint8(0x09), g.bgPalettes.data[3])
+	assert.Equal(t, uint16(0x09BB), g.bgPalettes.color(0, 1))
+}
+
+// TestRenderBackgroundPalettes tests that CGB backgrounds are drawn through
+// the palette selected by each tile's attributes.
+func TestRenderBackgroundPalettes(t *testing.T) {
+	g := prepareBackground(t, CGB)
+	fillTile(t, g, 0x8010, 3)
+
+	// The top left tile uses palette 5, where colour 3 is pure red.
+	writePaletteColor(t, g, gbAddrBCPS, 5, 3, 0x001F)
+	assert.NoError(t, g.bus.poke(gbAddrVBK, 0x01))
+	assert.NoError(t, g.bus.poke(gbAddrTileMap0, 0x05))
+	assert.NoError(t, g.bus.poke(gbAddrVBK, 0x00))
+
+	// Colour 0 of palette 0 is pure blue.
+	writePaletteColor(t, g, gbAddrBCPS, 0, 0, 0x7C00)
+	runFrames(t, g, 1)
+
+	fb := g.GetColorFramebuffer()
+	assert.Len(t, fb, gbScreenWidth*gbScreenHeight)
+	assert.Equal(t, uint16(0x001F), fb[0])
+	assert.Equal(t, uint16(0x7C00), fb[8])
+	assert.Equal(t, uint8(3), g.GetFramebuffer()[0])
+}
+
+// writePaletteColor sets a colour of one of the palettes behind the given
+// spec register.
+func writePaletteColor(t *testing.T, g *Gameboy, spec uint32, palette, index uint8, color uint16) {
+	assert.NoError(t, g.bus.poke(spec, gbPaletteAutoInc|(palette*8+index*2)))
+	assert.NoError(t, g.bus.poke(spec+1, uint8(color)))
+	assert.NoError(t, g.bus.poke(spec+1, uint8(color>>8)))
+}
+
+// TestRenderObjectPalettes tests that CGB objects are drawn through the
+// palette selected by their attributes.
+func TestRenderObjectPalettes(t *testing.T) {
+	g := prepareBackground(t, CGB)
+	assert.NoError(t, g.bus.poke(gbAddrLCDC, 0x93))
+	fillTile(t, g, 0x8020, 1)
+
+	writePaletteColor(t, g, gbAddrOCPS, 0, 1, 0x001F)
+	writePaletteColor(t, g, gbAddrOCPS, 1, 1, 0x03E0)
+	writePaletteColor(t, g, gbAddrBCPS, 0, 0, 0x7C00)
+
+	// Object 0 sits in the top left with palette 0, and object 1 sits 16
+	// pixels to the right of it with palette 1. The PPU is scanning OAM at
+	// power on, so it's written around the bus.
+	assert.NoError(t, pokeN(g.bus.mem, gbAddrOAM, []uint8{
+		16, 8, 0x02, 0x00,
+		16, 24, 0x02, 0x01,
+	}))
+	runFrames(t, g, 1)
+
+	fb := g.GetColorFramebuffer()
+	assert.Equal(t, uint16(0x001F), fb[0])
+	assert.Equal(t, uint16(0x001F), fb[7*gbScreenWidth+7])
+	assert.Equal(t, uint16(0x7C00), fb[8])
+	assert.Equal(t, uint16(0x03E0), fb[16])
+	assert.Equal(t, uint16(0x03E0), fb[7*gbScreenWidth+23])
+	assert.Equal(t, uint16(0x7C00), fb[8*gbScreenWidth+16])
+}
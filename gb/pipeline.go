@@ -0,0 +1,250 @@
+package gb
+
+// pixelPipeline draws a scanline a dot at a time, the way the PPU does during
+// pixel transfer. The background fetcher keeps the background FIFO topped
+// up, and each dot the mixer draws a pixel from the two FIFOs. When an
+// object starts at the next pixel, drawing and the background fetcher pause
+// while the object fetcher fetches it, which is what makes pixel transfer
+// longer on lines with objects.
+// TODO(guy): The window isn't drawn, and CGB objects should be prioritised
+// by OAM order alone.
+type pixelPipeline struct {
+	bg, obj    pixelFIFO
+	bgFetcher  *bgFetcher
+	objFetcher *objFetcher
+	lcdc, ly   uint8
+
+	objects [][]uint8 // OAM entries of the objects still to be fetched
+	discard int       // pixels left to drop for the fine scroll
+	x       int       // next pixel to draw
+	dots    int       // dots since the line started
+
+	line    [gbScreenWidth]pixelFIFOEntry
+	fromOBJ [gbScreenWidth]bool // whether each pixel of the line is an object's
+}
+
+func newPixelPipeline(vram *vramController, cgb bool) *pixelPipeline {
+	p := &pixelPipeline{}
+	p.bgFetcher = newBGFetcher(vram, &p.bg, cgb)
+	p.objFetcher = newOBJFetcher(vram, &p.obj, cgb)
+
+	return p
+}
+
+// start begins drawing line ly with the given LCDC and scroll registers. The
+// objects are the OAM entries selected for the line, in the order they're
+// fetched when they start at the same pixel.
+func (p *pixelPipeline) start(lcdc, ly, scx, scy uint8, objects [][]uint8) {
+	p.lcdc, p.ly = lcdc, ly
+	p.bg.clear()
+	p.obj.clear()
+	p.objects = append(p.objects[:0], objects...)
+	p.discard = int(scx % 8)
+	p.x, p.dots = 0, 0
+
+	tileMap := gbAddrTileMap0
+	if lcdc&gbLCDCBGTileMap != 0 {
+		tileMap = gbAddrTileMap1
+	}
+	p.bgFetcher.start(lcdc, tileMap, scx/8, ly+scy)
+}
+
+// done returns true once the whole line has been drawn.
+func (p *pixelPipeline) done() bool {
+	return p.x >= gbScreenWidth
+}
+
+// Tick moves the pipeline forward by the given number of dots.
+func (p *pixelPipeline) Tick(dots int) {
+	for i := 0; i < dots && !p.done(); i++ {
+		p.tick()
+	}
+}
+
+func (p *pixelPipeline) tick() {
+	p.dots++
+
+	if p.objFetcher.busy() {
+		p.objFetcher.Tick(1)
+		return
+	}
+	if p.lcdc&gbLCDCOBJEnable != 0 && p.discard == 0 && p.startObject() {
+		p.objFetcher.Tick(1)
+		return
+	}
+
+	p.bgFetcher.Tick(1)
+	px, isObj, ok := mixPixel(&p.bg, &p.obj)
+	if !ok {
+		return
+	}
+
+	if p.discard > 0 {
+		p.discard--
+		return
+	}
+	p.line[p.x], p.fromOBJ[p.x] = px, isObj
+	p.x++
+}
+
+// startObject starts fetching the first object that starts at the next
+// pixel, if there is one.
+func (p *pixelPipeline) startObject() bool {
+	for i, entry := range p.objects {
+		left := int(entry[1]) - gbOBJOffsetX
+		if left < 0 {
+			left = 0
+		}
+		if entry[1] == 0 || left != p.x {
+			continue
+		}
+
+		p.objects = append(p.objects[:i], p.objects[i+1:]...)
+		row := int(p.ly) - (int(entry[0]) - gbOBJOffsetY)
+		p.objFetcher.start(p.lcdc, entry, row)
+		return true
+	}
+
+	return false
+}
+
+// gbPixelPipelineState is the serialisable internal state of a
+// pixelPipeline, so that states saved partway through pixel transfer resume
+// drawing the line where they left off.
+type gbPixelPipelineState struct {
+	BG, OBJ    []gbPixelState
+	BGFetcher  gbBGFetcherState
+	OBJFetcher gbOBJFetcherState
+	LCDC, LY   uint8
+
+	Objects          [][]uint8
+	Discard, X, Dots int
+
+	Line    [gbScreenWidth]gbPixelState
+	FromOBJ [gbScreenWidth]bool
+}
+
+func (p *pixelPipeline) snapshot() gbPixelPipelineState {
+	s := gbPixelPipelineState{
+		BG:         p.bg.snapshot(),
+		OBJ:        p.obj.snapshot(),
+		BGFetcher:  p.bgFetcher.snapshot(),
+		OBJFetcher: p.objFetcher.snapshot(),
+		LCDC:       p.lcdc,
+		LY:         p.ly,
+		Discard:    p.discard,
+		X:          p.x,
+		Dots:       p.dots,
+		FromOBJ:    p.fromOBJ,
+	}
+	for _, entry := range p.objects {
+		s.Objects = append(s.Objects, append([]uint8(nil), entry...))
+	}
+	for x, px := range p.line {
+		s.Line[x] = px.snapshot()
+	}
+
+	return s
+}
+
+func (p *pixelPipeline) restore(s gbPixelPipelineState) {
+	p.bg.restore(s.BG)
+	p.obj.restore(s.OBJ)
+	p.bgFetcher.restore(s.BGFetcher)
+	p.objFetcher.restore(s.OBJFetcher)
+	p.lcdc, p.ly = s.LCDC, s.LY
+	p.objects = s.Objects
+	p.discard, p.x, p.dots = s.Discard, s.X, s.Dots
+	for x, px := range s.Line {
+		p.line[x] = px.entry()
+	}
+	p.fromOBJ = s.FromOBJ
+}
+
+// WithPixelPipeline makes the PPU draw each scanline a dot at a time through
+// its pixel FIFOs, instead of all at once at the end of pixel transfer. Pixel
+// transfer then takes as long as the FIFOs take to fill the line, which
+// includes the pauses for fetching objects. The window isn't drawn yet.
+func WithPixelPipeline() Option {
+	return func(g *Gameboy) {
+		g.usePipeline = true
+	}
+}
+
+// startPipeline starts the pixel pipeline on the current scanline. Pixel
+// transfer lasts until the pipeline has drawn the whole line.
+func (p *gbPPU) startPipeline() error {
+	lcdc, err := p.mem.read(gbAddrLCDC)
+	if err != nil {
+		return err
+	}
+	scy, err := p.mem.read(gbAddrSCY)
+	if err != nil {
+		return err
+	}
+	scx, err := p.mem.read(gbAddrSCX)
+	if err != nil {
+		return err
+	}
+	oam, err := p.oam.ReadSlice(gbAddrOAM, gbOAMEntries*gbOAMEntrySize)
+	if err != nil {
+		return err
+	}
+
+	height := gbOBJHeight
+	if lcdc&gbLCDCOBJSize != 0 {
+		height *= 2
+	}
+	p.pipeline.start(lcdc, uint8(p.ly), scx, scy, p.selectObjects(oam, height))
+	p.transferDots = gbDotsPerLine - gbOAMScanDots
+
+	return nil
+}
+
+// renderPipelineLine copies the line drawn by the pixel pipeline into the
+// framebuffers, mapping it through the palettes like renderLine.
+func (p *gbPPU) renderPipelineLine() error {
+	bgp, err := p.mem.read(gbAddrBGP)
+	if err != nil {
+		return err
+	}
+	obp0, err := p.mem.read(gbAddrOBP0)
+	if err != nil {
+		return err
+	}
+	obp1, err := p.mem.read(gbAddrOBP1)
+	if err != nil {
+		return err
+	}
+
+	line := p.frame[p.ly*gbScreenWidth : (p.ly+1)*gbScreenWidth]
+	shades := p.shades[p.ly*gbScreenWidth : (p.ly+1)*gbScreenWidth]
+	colorLine := p.colorFrame[p.ly*gbScreenWidth : (p.ly+1)*gbScreenWidth]
+	bgOff := !p.cgb && p.pipeline.lcdc&gbLCDCBGEnable == 0
+
+	for x, px := range p.pipeline.line {
+		line[x] = px.color
+		switch {
+		case p.pipeline.fromOBJ[x]:
+			obp := obp0
+			if px.palette != 0 && !p.cgb {
+				obp = obp1
+			}
+			shades[x] = gbShade(obp, px.color)
+			if p.objPalettes != nil {
+				colorLine[x] = p.objPalettes.color(px.palette, px.color)
+			}
+
+		case bgOff:
+			line[x], shades[x] = 0, 0
+
+		default:
+			shades[x] = gbShade(bgp, px.color)
+			if p.bgPalettes != nil {
+				colorLine[x] = p.bgPalettes.color(px.palette, px.color)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,141 @@
+package gb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// runPipeline draws line 0 with the given objects, and returns the number of
+// dots it took.
+func runPipeline(t *testing.T, p *pixelPipeline, lcdc, scx uint8, objects [][]uint8) int {
+	p.start(lcdc, 0, scx, 0, objects)
+	for i := 0; i < 1000 && !p.done(); i++ {
+		p.Tick(1)
+	}
+	assert.True(t, p.done())
+
+	return p.dots
+}
+
+// TestPixelPipelineObjectPenalty tests that fetching an object pauses the
+// pipeline for 6 dots, and that the object is drawn over the background.
+func TestPixelPipelineObjectPenalty(t *testing.T) {
+	vram := newVRAMController()
+	for i := uint32(0); i < gbTileBytes; i += 2 {
+		assert.NoError(t, vram.poke(0x8010+i, 0xFF))   // tile 1 is colour 1
+		assert.NoError(t, vram.poke(0x8020+i+1, 0xFF)) // tile 2 is colour 2
+	}
+	assert.NoError(t, vram.poke(gbAddrTileMap0+1, 0x01))
+
+	p := newPixelPipeline(vram, false)
+	const lcdc = 0x93
+	plain := runPipeline(t, p, lcdc, 0, nil)
+
+	// The object sits at x=8, over the second tile of the background.
+	withObject := runPipeline(t, p, lcdc, 0, [][]uint8{{16, 16, 0x02, 0x00}})
+	assert.Equal(t, gbFetchOBJDots, withObject-plain)
+
+	for x, expected := range map[int]uint8{0: 0, 7: 0, 8: 2, 15: 2, 16: 0} {
+		assert.Equal(t, expected, p.line[x].color, "x=%d", x)
+	}
+
+	// Objects are ignored while they're turned off in LCDC.
+	assert.Equal(t, plain, runPipeline(t, p, lcdc&^gbLCDCOBJEnable, 0, [][]uint8{{16, 16, 0x02, 0x00}}))
+	assert.Equal(t, uint8(1), p.line[8].color)
+}
+
+// TestPixelPipelineScroll tests dropping pixels for the fine scroll.
+func TestPixelPipelineScroll(t *testing.T) {
+	vram := newVRAMController()
+	assert.NoError(t, vram.poke(0x8010, 0x0F)) // the right half of tile 1 is colour 1
+	assert.NoError(t, vram.poke(gbAddrTileMap0, 0x01))
+
+	p := newPixelPipeline(vram, false)
+	plain := runPipeline(t, p, 0x91, 0, nil)
+	scrolled := runPipeline(t, p, 0x91, 4, nil)
+	assert.Equal(t, 4, scrolled-plain)
+	assert.Equal(t, uint8(1), p.line[0].color)
+	assert.Equal(t, uint8(1), p.line[3].color)
+	assert.Equal(t, uint8(0), p.line[4].color)
+}
+
+// TestPPUPixelPipeline tests that the PPU draws the same frame through the
+// pixel pipeline as it does in one go, and that the pipeline times pixel
+// transfer on lines with objects.
+func TestPPUPixelPipeline(t *testing.T) {
+	prepare := func(opts ...Option) *Gameboy {
+		g := NewGameboy(opts...)
+		assert.NoError(t, pokeN(g.bus, 0x100, testProgram))
+		assert.NoError(t, g.bus.poke(gbAddrLCDC, 0x93))
+		assert.NoError(t, g.bus.poke(gbAddrTileMap0, 0x01))
+		assert.NoError(t, g.bus.poke(gbAddrOBP1, 0x08)) // colour 1 is shade 2
+		fillTile(t, g, 0x8010, 3)
+		fillTile(t, g, 0x8020, 1)
+
+		// An object using OBP1 covers the first 8 lines, 16 pixels from the
+		// left of the screen.
+		assert.NoError(t, pokeN(g.bus.mem, gbAddrOAM, []uint8{16, 24, 0x02, gbOBJAttrDMGPalette}))
+		runFrames(t, g, 1)
+
+		return g
+	}
+	plain, piped := prepare(), prepare(WithPixelPipeline())
+
+	assert.Equal(t, plain.GetFramebuffer(), piped.GetFramebuffer())
+	assert.Equal(t, plain.ppu.(*gbPPU).MappedFramebuffer(), piped.ppu.(*gbPPU).MappedFramebuffer())
+
+	// Find out how long transfer took on a line with the object and one
+	// without.
+	transferDots := func(ly int) int {
+		_, err := piped.RunUntil(func(g *Gameboy) bool {
+			return g.ppu.Scanline() == ly && g.ppu.Mode() == gbPPUModeHBlank
+		}, gbCyclesPerFrame)
+		assert.NoError(t, err)
+
+		return piped.ppu.(*gbPPU).transferDots
+	}
+	assert.Equal(t, gbFetchOBJDots, transferDots(0)-transferDots(8))
+}
+
+// TestSaveLoadStatePixelPipeline tests that a state saved partway through
+// pixel transfer finishes drawing the line it was saved on.
+func TestSaveLoadStatePixelPipeline(t *testing.T) {
+	g := NewGameboy(WithPixelPipeline())
+	assert.NoError(t, pokeN(g.bus, 0x100, testProgram))
+	assert.NoError(t, g.bus.poke(gbAddrLCDC, 0x93))
+	fillTile(t, g, 0x8010, 3)
+	assert.NoError(t, pokeN(g.bus.mem, gbAddrOAM, []uint8{16, 24, 0x01, 0x00}))
+	p := g.ppu.(*gbPPU)
+
+	runUntil := func(ly, mode int, cond func() bool) uint64 {
+		n, err := g.RunUntil(func(g *Gameboy) bool {
+			return p.ly == ly && p.lcdMode == mode && cond()
+		}, 2*gbCyclesPerFrame)
+		assert.NoError(t, err)
+		return n
+	}
+	always := func() bool { return true }
+
+	// Save before the pipeline reaches the object on line 4.
+	runUntil(4, gbPPUModeTransfer, func() bool { return p.pipeline.x > 8 })
+	assert.True(t, p.pipeline.x < 16)
+	saved, err := g.SaveState()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	runUntil(4, gbPPUModeHBlank, always)
+	expectedDots := p.transferDots
+	expected := g.GetFramebuffer()[4*gbScreenWidth : 5*gbScreenWidth]
+	assert.Equal(t, uint8(3), expected[16])
+
+	// Line 10 doesn't have the object, and leaves the pipeline finished with
+	// different pixels. Loading should pick line 4 back up where it was.
+	runUntil(10, gbPPUModeHBlank, always)
+	assert.NoError(t, g.LoadState(saved))
+	copy(p.Framebuffer()[4*gbScreenWidth:], make([]uint8, gbScreenWidth))
+	assert.True(t, runUntil(4, gbPPUModeHBlank, always) < gbDotsPerLine)
+	assert.Equal(t, expectedDots, p.transferDots)
+	assert.Equal(t, expected, g.GetFramebuffer()[4*gbScreenWidth:5*gbScreenWidth])
+}
@@ -1,9 +1,301 @@
 package gb
 
-type ppu interface{}
+type ppu interface {
+	// Tick moves the PPU forward by the given number of quartz-cycles,
+	// updating the LCD registers as it moves through each scanline.
+	Tick(tcycles int) error
 
-type gbPPU struct{}
+	// Mode returns the PPU's current mode, and Scanline the line it's on.
+	Mode() int
+	Scanline() int
 
-func newGBPPU() *gbPPU {
-	return &gbPPU{}
+	// IsVRAMAccessible returns false while the PPU is reading video RAM, when
+	// the CPU is locked out of it.
+	IsVRAMAccessible() bool
+
+	// IsOAMAccessible returns false while the PPU is reading OAM, when the
+	// CPU is locked out of it.
+	IsOAMAccessible() bool
+
+	// SetVRAM replaces the video RAM the PPU draws from, which lets tests
+	// draw from memory they've set up themselves. The CPU's view of video RAM
+	// on the bus is left alone.
+	SetVRAM(r ram)
+
+	// SetVRAMBank selects the video RAM bank the CPU sees, like writing to
+	// VBK. The PPU itself reads from both banks regardless.
+	SetVRAMBank(bank int)
+
+	// setHeadless turns rendering off or on. The PPU keeps moving through its
+	// modes while it's off, but leaves the framebuffers alone.
+	setHeadless(bool)
+
+	// snapshot returns the PPU's internal state for save states, and restore
+	// loads it back.
+	snapshot() gbPPUState
+	restore(gbPPUState)
+
+	// Framebuffer returns the last rendered frame as colour indices, one byte
+	// per pixel from the top left.
+	Framebuffer() []uint8
+
+	// colorFramebuffer returns the last rendered frame in BGR555 colour. It's
+	// only drawn on models with colour palettes.
+	colorFramebuffer() []uint16
+}
+
+const (
+	gbPPUModeHBlank   = 0 // horizontal blanking period
+	gbPPUModeVBlank   = 1 // vertical blanking period
+	gbPPUModeOAMScan  = 2 // searching OAM for sprites on the scanline
+	gbPPUModeTransfer = 3 // transferring pixels to the LCD
+
+	gbDotsPerLine   = 456 // quartz-cycles per scanline
+	gbOAMScanDots   = 80
+	gbTransferDots  = 172 // without any scrolling or object penalties
+	gbVisibleLines  = 144
+	gbLinesPerFrame = 154
+
+	gbAddrSTAT uint32 = 0xFF41 // LCD status
+	gbAddrLY   uint32 = 0xFF44 // current scanline
+
+	gbSTATModeMask uint8 = 0x3 // 0b00000011
+
+	gbInterruptVBlank uint8 = 0x1 << 0
+)
+
+type gbPPU struct {
+	mem  ram
+	oam  ram // OAM, read around the bus so that the CPU lockout doesn't apply
+	vram ram // video RAM, usually a vramController
+	cgb  bool
+
+	bgPalettes  *gbPaletteRAM // nil unless the model has colour palettes
+	objPalettes *gbPaletteRAM
+
+	lcdMode int
+	dots    int // quartz-cycles into the current scanline
+	ly      int
+	wly     int // lines of the window drawn so far this frame
+
+	transferDots int            // length of pixel transfer on the current scanline
+	pipeline     *pixelPipeline // nil unless lines are drawn a dot at a time
+	headless     bool
+
+	frame      [gbScreenWidth * gbScreenHeight]uint8
+	shades     [gbScreenWidth * gbScreenHeight]uint8
+	colorFrame [gbScreenWidth * gbScreenHeight]uint16
+}
+
+func newGBPPU(mem ram, vram *vramController) *gbPPU {
+	return &gbPPU{
+		mem:     mem,
+		oam:     mem,
+		vram:    vram,
+		lcdMode: gbPPUModeOAMScan,
+	}
+}
+
+// gbPPUState is the serialisable internal state of a gbPPU.
+type gbPPUState struct {
+	Mode, Dots, LY, WLY, TransferDots int
+
+	Pipeline *gbPixelPipelineState // nil unless lines are drawn a dot at a time
+}
+
+func (p *gbPPU) snapshot() gbPPUState {
+	s := gbPPUState{
+		Mode:         p.lcdMode,
+		Dots:         p.dots,
+		LY:           p.ly,
+		WLY:          p.wly,
+		TransferDots: p.transferDots,
+	}
+	if p.pipeline != nil {
+		pipeline := p.pipeline.snapshot()
+		s.Pipeline = &pipeline
+	}
+
+	return s
+}
+
+func (p *gbPPU) restore(s gbPPUState) {
+	p.lcdMode, p.dots, p.ly, p.wly = s.Mode, s.Dots, s.LY, s.WLY
+	p.transferDots = s.TransferDots
+	if p.pipeline != nil && s.Pipeline != nil {
+		p.pipeline.restore(*s.Pipeline)
+	}
+}
+
+func (p *gbPPU) Mode() int {
+	return p.lcdMode
+}
+
+func (p *gbPPU) Scanline() int {
+	return p.ly
+}
+
+func (p *gbPPU) setHeadless(headless bool) {
+	p.headless = headless
+}
+
+func (p *gbPPU) IsVRAMAccessible() bool {
+	return p.lcdMode != gbPPUModeTransfer
+}
+
+func (p *gbPPU) IsOAMAccessible() bool {
+	return p.lcdMode != gbPPUModeOAMScan && p.lcdMode != gbPPUModeTransfer
+}
+
+func (p *gbPPU) SetVRAM(r ram) {
+	p.vram = r
+}
+
+// SetVRAMBank does nothing on models without a second bank, or if the video
+// RAM has been replaced with memory that doesn't have one.
+func (p *gbPPU) SetVRAMBank(bank int) {
+	if v, ok := p.vram.(*vramController); ok && p.cgb {
+		v.vbk = uint8(bank) & gbVBKBankMask
+	}
+}
+
+// vramByte returns the byte at the given VRAM address of the given bank.
+// Video RAM other than a vramController only has bank 0, and bank 1 reads as
+// zeroes.
+func (p *gbPPU) vramByte(bank int, addr uint32) uint8 {
+	if v, ok := p.vram.(*vramController); ok {
+		return v.bankByte(bank, addr)
+	}
+	if bank != 0 {
+		return 0
+	}
+
+	val, _ := p.vram.read(addr)
+	return val
+}
+
+func (p *gbPPU) Framebuffer() []uint8 {
+	return p.frame[:]
+}
+
+// MappedFramebuffer returns the last rendered frame as shades from 0 (white)
+// to 3 (black), mapped through BGP for the background and OBP0 or OBP1 for
+// objects.
+func (p *gbPPU) MappedFramebuffer() [gbScreenWidth * gbScreenHeight]uint8 {
+	return p.shades
+}
+
+func (p *gbPPU) colorFramebuffer() []uint16 {
+	return p.colorFrame[:]
+}
+
+func (p *gbPPU) Tick(tcycles int) error {
+	for i := 0; i < tcycles; i++ {
+		if err := p.tick(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// tick moves the PPU forward by a single quartz-cycle.
+func (p *gbPPU) tick() error {
+	p.dots++
+	if p.dots == gbDotsPerLine {
+		p.dots = 0
+		p.ly = (p.ly + 1) % gbLinesPerFrame
+		if p.ly == 0 {
+			p.wly = 0
+		}
+
+		if err := p.mem.poke(gbAddrLY, uint8(p.ly)); err != nil {
+			return err
+		}
+	}
+
+	// Pixels scrolled off the left of the line are still fetched and then
+	// discarded, costing a dot each. The pixel pipeline works out how long
+	// transfer takes by drawing the line.
+	if p.dots == gbOAMScanDots && p.ly < gbVisibleLines {
+		if p.pipeline != nil {
+			if err := p.startPipeline(); err != nil {
+				return err
+			}
+		} else {
+			scx, err := p.mem.read(gbAddrSCX)
+			if err != nil {
+				return err
+			}
+			p.transferDots = gbTransferDots + int(scx%8)
+		}
+	}
+	if p.pipeline != nil && p.ly < gbVisibleLines && p.dots >= gbOAMScanDots && !p.pipeline.done() {
+		p.pipeline.Tick(1)
+		if p.pipeline.done() {
+			p.transferDots = p.pipeline.dots
+		}
+	}
+
+	// Lines are drawn in one go when pixel transfer finishes, unless the
+	// pixel pipeline has drawn them a dot at a time.
+	mode := p.currentMode()
+	if p.lcdMode == gbPPUModeTransfer && mode == gbPPUModeHBlank && !p.headless {
+		render := p.renderLine
+		if p.pipeline != nil {
+			render = p.renderPipelineLine
+		}
+		if err := render(); err != nil {
+			return err
+		}
+	}
+
+	return p.setMode(mode)
+}
+
+// currentMode returns the mode the PPU should be in given its position in the
+// frame.
+func (p *gbPPU) currentMode() int {
+	switch {
+	case p.ly >= gbVisibleLines:
+		return gbPPUModeVBlank
+
+	case p.dots < gbOAMScanDots:
+		return gbPPUModeOAMScan
+
+	case p.dots < gbOAMScanDots+p.transferDots:
+		return gbPPUModeTransfer
+	}
+
+	return gbPPUModeHBlank
+}
+
+// setMode transitions the PPU into the given mode, reflecting it in the mode
+// bits of the STAT register and requesting the V-blank interrupt on entering
+// V-blank.
+func (p *gbPPU) setMode(mode int) error {
+	if mode == p.lcdMode {
+		return nil
+	}
+	p.lcdMode = mode
+
+	stat, err := p.mem.read(gbAddrSTAT)
+	if err != nil {
+		return err
+	}
+
+	stat = (stat &^ gbSTATModeMask) | uint8(mode)
+	if err := p.mem.poke(gbAddrSTAT, stat); err != nil {
+		return err
+	}
+
+	if mode != gbPPUModeVBlank {
+		return nil
+	}
+
+	flags, err := p.mem.read(gbAddrIF)
+	if err != nil {
+		return err
+	}
+	return p.mem.poke(gbAddrIF, flags|gbInterruptVBlank)
 }
@@ -0,0 +1,102 @@
+package gb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestPPUTick tests that the PPU moves through its modes as it's ticked.
+func TestPPUTick(t *testing.T) {
+	g := NewGameboy()
+	p := g.ppu
+
+	cases := []struct {
+		tcycles int
+		mode    int
+		line    int
+	}{
+		{79, gbPPUModeOAMScan, 0},
+		{1, gbPPUModeTransfer, 0},
+		{gbTransferDots, gbPPUModeHBlank, 0},
+		{gbDotsPerLine - gbOAMScanDots - gbTransferDots, gbPPUModeOAMScan, 1},
+		{gbDotsPerLine * (gbVisibleLines - 1), gbPPUModeVBlank, gbVisibleLines},
+		{gbDotsPerLine * (gbLinesPerFrame - gbVisibleLines), gbPPUModeOAMScan, 0},
+	}
+
+	for _, c := range cases {
+		assert.NoError(t, p.Tick(c.tcycles))
+		assert.Equal(t, c.mode, p.Mode())
+		assert.Equal(t, c.line, p.Scanline())
+	}
+
+	ly, err := g.bus.read(gbAddrLY)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0), ly)
+}
+
+// TestPPUVBlankInterrupt tests that entering V-blank requests an interrupt.
+func TestPPUVBlankInterrupt(t *testing.T) {
+	g := NewGameboy()
+
+	assert.NoError(t, g.ppu.Tick(gbDotsPerLine*gbVisibleLines-1))
+	flags, err := g.bus.read(gbAddrIF)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0), flags&gbInterruptVBlank)
+
+	assert.NoError(t, g.ppu.Tick(1))
+	flags, err = g.bus.read(gbAddrIF)
+	assert.NoError(t, err)
+	assert.Equal(t, gbInterruptVBlank, flags&gbInterruptVBlank)
+}
+
+// mockPPU wraps a PPU to record the quartz-cycles it's ticked by.
+type mockPPU struct {
+	ppu
+	ticks []int
+}
+
+func (m *mockPPU) Tick(tcycles int) error {
+	m.ticks = append(m.ticks, tcycles)
+	return m.ppu.Tick(tcycles)
+}
+
+// TestStepTicksPPU tests that each step ticks the PPU by a quartz-cycle.
+func TestStepTicksPPU(t *testing.T) {
+	g := prepareGameboy(t, testProgram)
+	m := &mockPPU{ppu: g.ppu}
+	g.ppu = m
+
+	runInstructions(t, g, 10)
+	if !assert.Len(t, m.ticks, int(g.CycleCount())) {
+		return
+	}
+	for _, n := range m.ticks {
+		assert.Equal(t, 1, n)
+	}
+}
+
+// TestSetVRAM tests that the PPU draws from video RAM it's been given in
+// place of its own, while the CPU still sees the original.
+func TestSetVRAM(t *testing.T) {
+	g := prepareBackground(t, DMG)
+	fillTile(t, g, 0x8010, 1)
+
+	mem := newGBRAM()
+	assert.NoError(t, mem.poke(gbAddrTileMap0, 0x01))
+	for row := uint32(0); row < 8; row++ {
+		assert.NoError(t, mem.poke(0x8010+row*2, 0xFF))
+		assert.NoError(t, mem.poke(0x8010+row*2+1, 0xFF))
+	}
+	g.ppu.SetVRAM(mem)
+	g.ppu.SetVRAMBank(1) // no second bank to select
+	runFrames(t, g, 1)
+
+	assert.Equal(t, uint8(3), g.GetFramebuffer()[0])
+	val, err := g.bus.read(0x8010)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0xFF), val)
+	val, err = g.bus.read(0x8011)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0x00), val)
+}
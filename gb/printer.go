@@ -0,0 +1,233 @@
+package gb
+
+const (
+	gbPrinterMagic1 uint8 = 0x88 // every packet starts with 0x88 0x33
+	gbPrinterMagic2 uint8 = 0x33
+	gbPrinterID     uint8 = 0x81 // sent back in the first byte after a packet
+
+	gbPrinterCmdInit   uint8 = 0x01 // clear the image buffer
+	gbPrinterCmdPrint  uint8 = 0x02 // print the image buffer
+	gbPrinterCmdData   uint8 = 0x04 // append tile data to the image buffer
+	gbPrinterCmdStatus uint8 = 0x0F // just ask for the status
+
+	gbPrinterStatusChecksum uint8 = 0x1 << 0 // the last packet was corrupted
+	gbPrinterStatusData     uint8 = 0x1 << 3 // there's data waiting to print
+
+	gbPrinterTilesPerRow = 20 // tiles across the 160 pixel width
+	gbPrinterPaletteArg  = 2  // index of the palette in print arguments
+)
+
+// The parts of a printer packet, in the order they're sent.
+const (
+	gbPrinterStateMagic1 = iota
+	gbPrinterStateMagic2
+	gbPrinterStateCommand
+	gbPrinterStateCompression
+	gbPrinterStateLengthLo
+	gbPrinterStateLengthHi
+	gbPrinterStateData
+	gbPrinterStateChecksumLo
+	gbPrinterStateChecksumHi
+	gbPrinterStateID
+	gbPrinterStateStatus
+)
+
+var _ SerialHandler = (*PrinterDevice)(nil)
+
+// PrinterDevice emulates a Game Boy Printer plugged into the link cable. The
+// gameboy sends it packets of 2bpp tile data, which it collects until it's
+// told to print, and then passes the image to the print callback.
+type PrinterDevice struct {
+	printImage func(data []byte)
+
+	state    int
+	packet   gbPrinterPacket
+	received int // data bytes received in the current packet
+	status   uint8
+	buffer   []uint8 // decompressed tile data waiting to print
+}
+
+// gbPrinterPacket is a packet received by a PrinterDevice.
+type gbPrinterPacket struct {
+	command, compression uint8
+	length               uint16
+	data                 []uint8
+	checksum             uint16
+}
+
+// NewPrinterDevice returns a printer that calls printImage with each image it
+// prints. The image is 160 pixels wide, one byte per pixel from the top left,
+// with each pixel a shade from 0 (white) to 3 (black) mapped through the
+// palette sent with the print command.
+func NewPrinterDevice(printImage func(data []byte)) *PrinterDevice {
+	return &PrinterDevice{printImage: printImage}
+}
+
+// Transfer receives a byte of a packet from the gameboy. The printer sends
+// back zeroes until the packet is over, and then its ID and status.
+func (p *PrinterDevice) Transfer(out uint8) uint8 {
+	switch p.state {
+	case gbPrinterStateMagic1:
+		if out == gbPrinterMagic1 {
+			p.state = gbPrinterStateMagic2
+		}
+		return 0x00
+
+	case gbPrinterStateMagic2:
+		p.state = gbPrinterStateMagic1
+		if out == gbPrinterMagic2 {
+			p.state = gbPrinterStateCommand
+		}
+		return 0x00
+
+	case gbPrinterStateCommand:
+		p.packet = gbPrinterPacket{command: out}
+		p.state = gbPrinterStateCompression
+
+	case gbPrinterStateCompression:
+		p.packet.compression = out
+		p.state = gbPrinterStateLengthLo
+
+	case gbPrinterStateLengthLo:
+		p.packet.length = uint16(out)
+		p.state = gbPrinterStateLengthHi
+
+	case gbPrinterStateLengthHi:
+		p.packet.length |= uint16(out) << 8
+		p.packet.data = make([]uint8, 0, p.packet.length)
+		p.state = gbPrinterStateData
+		if p.packet.length == 0 {
+			p.state = gbPrinterStateChecksumLo
+		}
+
+	case gbPrinterStateData:
+		p.packet.data = append(p.packet.data, out)
+		if len(p.packet.data) == int(p.packet.length) {
+			p.state = gbPrinterStateChecksumLo
+		}
+
+	case gbPrinterStateChecksumLo:
+		p.packet.checksum = uint16(out)
+		p.state = gbPrinterStateChecksumHi
+
+	case gbPrinterStateChecksumHi:
+		p.packet.checksum |= uint16(out) << 8
+		p.handle()
+		p.state = gbPrinterStateID
+
+	case gbPrinterStateID:
+		p.state = gbPrinterStateStatus
+		return gbPrinterID
+
+	case gbPrinterStateStatus:
+		p.state = gbPrinterStateMagic1
+		return p.status
+	}
+
+	return 0x00
+}
+
+// sum returns the checksum the packet should have, which is the sum of every
+// byte after the magic bytes.
+func (pk *gbPrinterPacket) sum() uint16 {
+	sum := uint16(pk.command) + uint16(pk.compression) +
+		uint16(pk.length&0xFF) + uint16(pk.length>>8)
+	for _, b := range pk.data {
+		sum += uint16(b)
+	}
+
+	return sum
+}
+
+// handle carries out a complete packet's command.
+func (p *PrinterDevice) handle() {
+	pk := &p.packet
+	if pk.checksum != pk.sum() {
+		p.status |= gbPrinterStatusChecksum
+		return
+	}
+	p.status &^= gbPrinterStatusChecksum
+
+	switch pk.command {
+	case gbPrinterCmdInit:
+		p.buffer = p.buffer[:0]
+
+	case gbPrinterCmdData:
+		if pk.compression != 0 {
+			p.buffer = append(p.buffer, decompressPrinterData(pk.data)...)
+		} else {
+			p.buffer = append(p.buffer, pk.data...)
+		}
+
+	case gbPrinterCmdPrint:
+		palette := uint8(0xE4) // 0b11100100, shade n for colour n
+		if len(pk.data) > gbPrinterPaletteArg && pk.data[gbPrinterPaletteArg] != 0 {
+			palette = pk.data[gbPrinterPaletteArg]
+		}
+		if p.printImage != nil {
+			p.printImage(p.image(palette))
+		}
+		p.buffer = p.buffer[:0]
+	}
+
+	p.status &^= gbPrinterStatusData
+	if len(p.buffer) > 0 {
+		p.status |= gbPrinterStatusData
+	}
+}
+
+// image decodes the image buffer, which holds rows of 20 tiles each, into
+// shades mapped through the given palette. Incomplete rows of tiles are
+// dropped.
+func (p *PrinterDevice) image(palette uint8) []byte {
+	const rowBytes = gbPrinterTilesPerRow * gbTileBytes
+
+	rows := len(p.buffer) / rowBytes
+	img := make([]byte, rows*8*gbScreenWidth)
+	for i := 0; i < rows*gbPrinterTilesPerRow; i++ {
+		tile := p.buffer[i*gbTileBytes : (i+1)*gbTileBytes]
+		x0, y0 := i%gbPrinterTilesPerRow*8, i/gbPrinterTilesPerRow*8
+
+		for y := 0; y < 8; y++ {
+			lo, hi := tile[y*2], tile[y*2+1]
+			for x := 0; x < 8; x++ {
+				bit := uint(7 - x)
+				color := (lo>>bit)&0x1 | ((hi>>bit)&0x1)<<1
+				img[(y0+y)*gbScreenWidth+x0+x] = gbShade(palette, color)
+			}
+		}
+	}
+
+	return img
+}
+
+// decompressPrinterData expands run-length encoded printer data. Each run
+// starts with a byte n: if bit 7 is set, the next byte is repeated (n&0x7F)+2
+// times, and otherwise the next n+1 bytes are copied as they are.
+func decompressPrinterData(data []uint8) []uint8 {
+	var res []uint8
+	for i := 0; i < len(data); {
+		n := int(data[i])
+		i++
+
+		if n&0x80 != 0 {
+			if i >= len(data) {
+				break
+			}
+			for j := 0; j < n&0x7F+2; j++ {
+				res = append(res, data[i])
+			}
+			i++
+			continue
+		}
+
+		end := i + n + 1
+		if end > len(data) {
+			end = len(data)
+		}
+		res = append(res, data[i:end]...)
+		i = end
+	}
+
+	return res
+}
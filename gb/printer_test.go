@@ -0,0 +1,117 @@
+package gb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// sendPrinterPacket sends a packet to the printer byte by byte, checking
+// that it only responds after the checksum, and returns its status.
+func sendPrinterPacket(t *testing.T, p *PrinterDevice, cmd, compression uint8,
+	data []uint8, corrupt bool) uint8 {
+
+	pk := gbPrinterPacket{command: cmd, compression: compression,
+		length: uint16(len(data)), data: data}
+	sum := pk.sum()
+	if corrupt {
+		sum++
+	}
+
+	packet := []uint8{gbPrinterMagic1, gbPrinterMagic2, cmd, compression,
+		uint8(len(data)), uint8(len(data) >> 8)}
+	packet = append(packet, data...)
+	packet = append(packet, uint8(sum), uint8(sum>>8))
+	for _, b := range packet {
+		assert.Equal(t, uint8(0x00), p.Transfer(b))
+	}
+
+	assert.Equal(t, gbPrinterID, p.Transfer(0x00))
+	return p.Transfer(0x00)
+}
+
+// printerBand returns two rows of 20 tiles, with every pixel in the first row
+// set to colour 1 and every pixel in the second to colour 2.
+func printerBand() []uint8 {
+	data := make([]uint8, 2*gbPrinterTilesPerRow*gbTileBytes)
+	for i := 0; i < len(data); i += 2 {
+		if i < len(data)/2 {
+			data[i] = 0xFF
+		} else {
+			data[i+1] = 0xFF
+		}
+	}
+
+	return data
+}
+
+// TestPrinterDevice tests printing an image sent in two data packets.
+func TestPrinterDevice(t *testing.T) {
+	var images [][]byte
+	p := NewPrinterDevice(func(data []byte) { images = append(images, data) })
+
+	assert.Equal(t, uint8(0), sendPrinterPacket(t, p, gbPrinterCmdInit, 0, nil, false))
+	status := sendPrinterPacket(t, p, gbPrinterCmdData, 0, printerBand(), false)
+	assert.Equal(t, gbPrinterStatusData, status)
+	sendPrinterPacket(t, p, gbPrinterCmdData, 0, printerBand(), false)
+	sendPrinterPacket(t, p, gbPrinterCmdData, 0, nil, false) // end of data
+
+	// Print with a palette that swaps shades 1 and 2.
+	status = sendPrinterPacket(t, p, gbPrinterCmdPrint, 0, []uint8{0x01, 0x13, 0xD8, 0x40}, false)
+	assert.Equal(t, uint8(0), status)
+
+	if !assert.Len(t, images, 1) || !assert.Len(t, images[0], gbScreenWidth*32) {
+		return
+	}
+	for y := 0; y < 32; y++ {
+		row := images[0][y*gbScreenWidth : (y+1)*gbScreenWidth]
+		expected := uint8(2)
+		if y%16 >= 8 {
+			expected = 1
+		}
+		for x, shade := range row {
+			if !assert.Equal(t, expected, shade, "pixel (%d, %d)", x, y) {
+				return
+			}
+		}
+	}
+}
+
+// TestPrinterChecksum tests that corrupted packets are flagged and dropped.
+func TestPrinterChecksum(t *testing.T) {
+	p := NewPrinterDevice(nil)
+
+	status := sendPrinterPacket(t, p, gbPrinterCmdData, 0, printerBand(), true)
+	assert.Equal(t, gbPrinterStatusChecksum, status)
+	assert.Empty(t, p.buffer)
+
+	status = sendPrinterPacket(t, p, gbPrinterCmdStatus, 0, nil, false)
+	assert.Equal(t, uint8(0), status)
+}
+
+// TestPrinterCompression tests decompressing run-length encoded data.
+func TestPrinterCompression(t *testing.T) {
+	data := []uint8{0x82, 0xAA, 0x01, 0x12, 0x34}
+	assert.Equal(t, []uint8{0xAA, 0xAA, 0xAA, 0xAA, 0x12, 0x34}, decompressPrinterData(data))
+}
+
+// TestPrinterSerial tests that the printer works over the link cable.
+func TestPrinterSerial(t *testing.T) {
+	g := NewGameboy()
+	p := NewPrinterDevice(nil)
+	g.SetSerialHandler(p)
+
+	send := func(b uint8) uint8 {
+		assert.NoError(t, g.bus.poke(gbAddrSB, b))
+		assert.NoError(t, g.bus.poke(gbAddrSC, gbSCTransfer|gbSCInternalClock))
+		val, err := g.bus.read(gbAddrSB)
+		assert.NoError(t, err)
+		return val
+	}
+
+	for _, b := range []uint8{0x88, 0x33, 0x0F, 0x00, 0x00, 0x00, 0x0F, 0x00} {
+		assert.Equal(t, uint8(0x00), send(b))
+	}
+	assert.Equal(t, gbPrinterID, send(0x00))
+	assert.Equal(t, uint8(0x00), send(0x00))
+}
@@ -0,0 +1,65 @@
+package gb
+
+import "sort"
+
+// ProfileEntry holds the totals for one type of instruction, such as LD_R_N.
+type ProfileEntry struct {
+	Instruction string
+	Count       uint64 // times the instruction was executed
+	Cycles      uint64 // quartz-cycles spent executing the instruction
+}
+
+// Profiler tallies the instructions executed by a gameboy by type, to help
+// track down where a game spends its time.
+type Profiler struct {
+	entries map[gbOpcodeType]*ProfileEntry
+}
+
+func newProfiler() *Profiler {
+	return &Profiler{
+		entries: make(map[gbOpcodeType]*ProfileEntry),
+	}
+}
+
+func (p *Profiler) record(op *gbOpcode, cycles int) {
+	e, ok := p.entries[op.tipe]
+	if !ok {
+		e = &ProfileEntry{Instruction: op.tipe.String()}
+		p.entries[op.tipe] = e
+	}
+
+	e.Count++
+	e.Cycles += uint64(cycles)
+}
+
+// TopN returns the n instruction types that have taken the most cycles, most
+// expensive first. It returns nil if n is negative.
+func (p *Profiler) TopN(n int) []ProfileEntry {
+	if n < 0 {
+		return nil
+	}
+
+	res := make([]ProfileEntry, 0, len(p.entries))
+	for _, e := range p.entries {
+		res = append(res, *e)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Cycles != res[j].Cycles {
+			return res[i].Cycles > res[j].Cycles
+		}
+		return res[i].Instruction < res[j].Instruction
+	})
+
+	if n < len(res) {
+		res = res[:n]
+	}
+	return res
+}
+
+// EnableProfiling starts tallying executed instructions, returning the
+// profiler that holds the results.
+func (g *Gameboy) EnableProfiling() *Profiler {
+	g.profiler = newProfiler()
+	return g.profiler
+}
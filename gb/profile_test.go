@@ -0,0 +1,27 @@
+package gb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestProfiler tests tallying the instructions in a loop.
+func TestProfiler(t *testing.T) {
+	g := prepareGameboy(t, []uint8{
+		0x00,       // 0x100: NOP
+		0x00,       // 0x101: NOP
+		0x18, 0xFC, // 0x102: JR -4
+	})
+	p := g.EnableProfiling()
+	runInstructions(t, g, 150)
+
+	// JR takes three times as long as NOP, so it comes out on top.
+	assert.Equal(t, []ProfileEntry{
+		{"JR_N", 50, 50 * 12},
+		{"NOP", 100, 100 * 4},
+	}, p.TopN(2))
+	assert.Len(t, p.TopN(5), 2)
+	assert.Empty(t, p.TopN(0))
+	assert.Nil(t, p.TopN(-1))
+}
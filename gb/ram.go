@@ -5,6 +5,11 @@ import "errors"
 type ram interface {
 	poke(uint32, uint8) error
 	read(uint32) (uint8, error)
+
+	// ReadSlice returns n bytes starting at the given address. The result may
+	// alias the underlying memory, so callers must not modify it or hold on
+	// to it across writes.
+	ReadSlice(addr uint32, n uint32) ([]uint8, error)
 }
 
 const (
@@ -13,7 +18,9 @@ const (
 )
 
 var (
-	gbErrOutOfBounds = errors.New("gbRAM: address isn't within 64Kb memory bounds")
+	gbErrOutOfBounds    = errors.New("gbRAM: address isn't within 64Kb memory bounds")
+	gbErrBufferTooSmall = errors.New("gbRAM: buffer is too small for the read")
+	gbErrSnapshotSize   = errors.New("gbRAM: snapshot isn't the size of memory")
 )
 
 type gbRAM struct {
@@ -41,18 +48,72 @@ func (r *gbRAM) read(addr uint32) (uint8, error) {
 	return r.mem[addr], nil
 }
 
+func (r *gbRAM) ReadSlice(addr, n uint32) ([]uint8, error) {
+	if addr+n > gbMaxAddress {
+		return nil, gbErrOutOfBounds
+	}
+
+	return r.mem[addr : addr+n], nil
+}
+
+// Clear zeroes all of memory.
+func (r *gbRAM) Clear() {
+	r.mem = [gbMaxAddress]uint8{}
+}
+
+// Fill sets every byte of memory to val.
+func (r *gbRAM) Fill(val uint8) {
+	// Doubling copies are about as fast as a memset.
+	r.mem[0] = val
+	for i := 1; i < gbMaxAddress; i *= 2 {
+		copy(r.mem[i:], r.mem[:i])
+	}
+}
+
+// Snapshot returns a copy of the whole of memory.
+func (r *gbRAM) Snapshot() []byte {
+	snap := make([]byte, gbMaxAddress)
+	copy(snap, r.mem[:])
+
+	return snap
+}
+
+// Restore overwrites the whole of memory with a snapshot taken by Snapshot.
+func (r *gbRAM) Restore(snap []byte) error {
+	if len(snap) != gbMaxAddress {
+		return gbErrSnapshotSize
+	}
+
+	copy(r.mem[:], snap)
+	return nil
+}
+
 // readN is a utility function for reading multiple bytes from a given address.
 func readN(r ram, addr, n uint32) ([]uint8, error) {
-	var res []uint8
+	res := make([]uint8, n)
+	if err := readNInto(r, addr, n, res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// readNInto is like readN, but writes the bytes into the first n elements of
+// the given buffer instead of allocating a new one.
+func readNInto(r ram, addr, n uint32, buf []uint8) error {
+	if uint32(len(buf)) < n {
+		return gbErrBufferTooSmall
+	}
+
 	for i := uint32(0); i < n; i++ {
 		b, err := r.read(addr + i)
 		if err != nil {
-			return nil, err
+			return err
 		}
-		res = append(res, b)
+		buf[i] = b
 	}
 
-	return res, nil
+	return nil
 }
 
 // pokeN is a utility function for writing multiple bytes to the given address.
@@ -65,3 +126,52 @@ func pokeN(r ram, addr uint32, vals []uint8) error {
 
 	return nil
 }
+
+// pokeNAtomic is like pokeN, but validates every address before writing, so
+// that memory is left untouched if any of them are out of bounds.
+func pokeNAtomic(r ram, addr uint32, vals []uint8) error {
+	for i := range vals {
+		if _, err := r.read(addr + uint32(i)); err != nil {
+			return err
+		}
+	}
+
+	return pokeN(r, addr, vals)
+}
+
+// readWord reads a little-endian 16-bit word from the given address.
+func readWord(r ram, addr uint32) (uint16, error) {
+	lo, err := r.read(addr)
+	if err != nil {
+		return 0, err
+	}
+
+	hi, err := r.read(addr + 1)
+	if err != nil {
+		return 0, err
+	}
+
+	return uint16(hi)<<8 | uint16(lo), nil
+}
+
+// pokeWord writes a little-endian 16-bit word to the given address. The high
+// byte is written first, which is the order the CPU writes the stack in.
+func pokeWord(r ram, addr uint32, val uint16) error {
+	if err := r.poke(addr+1, uint8(val>>8)); err != nil {
+		return err
+	}
+
+	return r.poke(addr, uint8(val))
+}
+
+// copyRegion copies length bytes from src, starting at srcStart, to dst,
+// starting at dstStart. Every address is validated before anything is
+// written, so dst is left untouched if the copy fails.
+func copyRegion(dst, src ram, srcStart, dstStart, length uint32) error {
+	vals, err := readN(src, srcStart, length)
+	if err != nil {
+		return err
+	}
+
+	return pokeNAtomic(dst, dstStart, vals)
+}
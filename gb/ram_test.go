@@ -0,0 +1,165 @@
+package gb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mockAccess is a single memory access recorded by mockRAM, along with the
+// CPU's total cycles once it completed.
+type mockAccess struct {
+	addr  uint32
+	val   uint8
+	cycle uint64
+}
+
+// mockRAM is flat memory that records every access made to it, so that tests
+// can check exactly which accesses an operation makes and in what order.
+type mockRAM struct {
+	gbRAM
+
+	cpu   *gbCPU // stamps each access with its total cycles
+	reads []mockAccess
+	pokes []mockAccess
+}
+
+func newMockRAM(c *gbCPU) *mockRAM {
+	return &mockRAM{cpu: c}
+}
+
+// cycle returns the cycle to stamp on the access being recorded.
+func (m *mockRAM) cycle() uint64 {
+	return m.cpu.TotalCycles()
+}
+
+func (m *mockRAM) poke(addr uint32, val uint8) error {
+	if err := m.gbRAM.poke(addr, val); err != nil {
+		return err
+	}
+
+	m.pokes = append(m.pokes, mockAccess{addr, val, m.cycle()})
+	return nil
+}
+
+func (m *mockRAM) read(addr uint32) (uint8, error) {
+	val, err := m.gbRAM.read(addr)
+	if err != nil {
+		return 0, err
+	}
+
+	m.reads = append(m.reads, mockAccess{addr, val, m.cycle()})
+	return val, nil
+}
+
+func (m *mockRAM) ReadSlice(addr, n uint32) ([]uint8, error) {
+	return readN(m, addr, n)
+}
+
+// reset forgets the accesses recorded so far.
+func (m *mockRAM) reset() {
+	m.reads, m.pokes = nil, nil
+}
+
+// TestWord tests reading and writing little-endian 16-bit words.
+func TestWord(t *testing.T) {
+	r := newGBRAM()
+
+	assert.NoError(t, pokeWord(r, 0x8000, 0x1234))
+	assert.Equal(t, uint8(0x34), r.mem[0x8000])
+	assert.Equal(t, uint8(0x12), r.mem[0x8001])
+
+	val, err := readWord(r, 0x8000)
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(0x1234), val)
+
+	_, err = readWord(r, 0xFFFF)
+	assert.Equal(t, gbErrOutOfBounds, err)
+	assert.Equal(t, gbErrOutOfBounds, pokeWord(r, 0xFFFF, 0x1234))
+}
+
+// TestPokeNAtomic tests that a failed write leaves memory untouched.
+func TestPokeNAtomic(t *testing.T) {
+	r := newGBRAM()
+
+	err := pokeNAtomic(r, 0xFFFE, []uint8{0x01, 0x02, 0x03})
+	assert.Equal(t, gbErrOutOfBounds, err)
+	assert.Equal(t, uint8(0x00), r.mem[0xFFFE])
+	assert.Equal(t, uint8(0x00), r.mem[0xFFFF])
+
+	// pokeN, by comparison, writes as far as it can.
+	err = pokeN(r, 0xFFFE, []uint8{0x01, 0x02, 0x03})
+	assert.Equal(t, gbErrOutOfBounds, err)
+	assert.Equal(t, uint8(0x01), r.mem[0xFFFE])
+
+	assert.NoError(t, pokeNAtomic(r, 0xFFFD, []uint8{0x04, 0x05, 0x06}))
+	assert.Equal(t, []uint8{0x04, 0x05, 0x06}, r.mem[0xFFFD:])
+}
+
+// TestCopyRegion tests copying memory between regions, and that a failed
+// copy leaves the destination untouched.
+func TestCopyRegion(t *testing.T) {
+	src, dst := newGBRAM(), newGBRAM()
+	for i := uint32(0); i < 0x100; i++ {
+		src.mem[0x1000+i] = uint8(i ^ 0xA5)
+	}
+
+	assert.NoError(t, copyRegion(dst, src, 0x1000, 0xC000, 0x100))
+	assert.Equal(t, src.mem[0x1000:0x1100], dst.mem[0xC000:0xC100])
+
+	assert.Equal(t, gbErrOutOfBounds, copyRegion(dst, src, 0xFF80, 0x2000, 0x100))
+	assert.Equal(t, gbErrOutOfBounds, copyRegion(dst, src, 0x1000, 0xFF80, 0x100))
+	assert.Equal(t, make([]uint8, 0x100), dst.mem[0x2000:0x2100])
+	assert.Equal(t, make([]uint8, 0x80), dst.mem[0xFF80:])
+}
+
+// TestRAMFillClear tests filling and clearing the whole of memory.
+func TestRAMFillClear(t *testing.T) {
+	r := newGBRAM()
+
+	r.Fill(0xDE)
+	for _, addr := range []uint32{0x0000, 0x1234, 0xC000, 0xFFFF} {
+		val, err := r.read(addr)
+		assert.NoError(t, err)
+		assert.Equal(t, uint8(0xDE), val)
+	}
+
+	r.Clear()
+	for _, addr := range []uint32{0x0000, 0x1234, 0xC000, 0xFFFF} {
+		val, err := r.read(addr)
+		assert.NoError(t, err)
+		assert.Equal(t, uint8(0x00), val)
+	}
+}
+
+// BenchmarkRAMFill measures filling the whole of memory.
+func BenchmarkRAMFill(b *testing.B) {
+	r := newGBRAM()
+	b.SetBytes(gbMaxAddress)
+	for i := 0; i < b.N; i++ {
+		r.Fill(uint8(i))
+	}
+}
+
+// TestRAMSnapshotRestore tests restoring memory from a snapshot.
+func TestRAMSnapshotRestore(t *testing.T) {
+	r := newGBRAM()
+	for i := range r.mem {
+		r.mem[i] = uint8(i * 7)
+	}
+
+	snap := r.Snapshot()
+	r.Fill(0xFF)
+	assert.NoError(t, r.Restore(snap))
+	for i := range r.mem {
+		if !assert.Equal(t, uint8(i*7), r.mem[i]) {
+			break
+		}
+	}
+
+	// Snapshots are copies, so later writes don't affect them.
+	r.mem[0x1234] = 0x00
+	assert.Equal(t, uint8(0x6C), snap[0x1234])
+
+	assert.Equal(t, gbErrSnapshotSize, r.Restore(snap[1:]))
+}
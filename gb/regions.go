@@ -0,0 +1,27 @@
+package gb
+
+// The gameboy's memory map. Each region runs from its start address to its
+// end address inclusive.
+const (
+	gbAddrROMBank0    uint32 = 0x0000 // fixed cartridge ROM bank
+	gbAddrROMBankN    uint32 = 0x4000 // switchable cartridge ROM bank
+	gbAddrROMEnd      uint32 = 0x7FFF
+	gbAddrVRAM        uint32 = 0x8000 // video RAM
+	gbAddrVRAMEnd     uint32 = 0x9FFF
+	gbAddrExtRAM      uint32 = 0xA000 // cartridge RAM
+	gbAddrExtRAMEnd   uint32 = 0xBFFF
+	gbAddrWRAM0       uint32 = 0xC000 // fixed work RAM bank
+	gbAddrWRAMX       uint32 = 0xD000 // switchable work RAM bank (CGB only)
+	gbAddrWRAMXEnd    uint32 = 0xDFFF
+	gbAddrEchoRAM     uint32 = 0xE000 // mirror of 0xC000-0xDDFF
+	gbAddrEchoRAMEnd  uint32 = 0xFDFF
+	gbAddrOAM         uint32 = 0xFE00 // object attribute memory
+	gbAddrOAMEnd      uint32 = 0xFE9F
+	gbAddrUnusable    uint32 = 0xFEA0
+	gbAddrUnusableEnd uint32 = 0xFEFF
+	gbAddrIO          uint32 = 0xFF00 // I/O registers
+	gbAddrIOEnd       uint32 = 0xFF7F
+	gbAddrHRAM        uint32 = 0xFF80 // high RAM
+	gbAddrHRAMEnd     uint32 = 0xFFFE
+	gbAddrIE          uint32 = 0xFFFF // interrupt enable
+)
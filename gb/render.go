@@ -0,0 +1,290 @@
+package gb
+
+import "sort"
+
+const (
+	gbScreenWidth  = 160
+	gbScreenHeight = gbVisibleLines
+
+	gbAddrLCDC uint32 = 0xFF40 // LCD control
+	gbAddrSCY  uint32 = 0xFF42 // background scroll Y
+	gbAddrSCX  uint32 = 0xFF43 // background scroll X
+	gbAddrBGP  uint32 = 0xFF47 // background palette (DMG)
+	gbAddrOBP0 uint32 = 0xFF48 // object palette 0 (DMG)
+	gbAddrOBP1 uint32 = 0xFF49 // object palette 1 (DMG)
+	gbAddrWY   uint32 = 0xFF4A // window Y position
+	gbAddrWX   uint32 = 0xFF4B // window X position, plus 7
+
+	gbLCDCBGEnable  uint8 = 0x1 << 0 // background on (DMG), priority (CGB)
+	gbLCDCOBJEnable uint8 = 0x1 << 1
+	gbLCDCOBJSize   uint8 = 0x1 << 2 // objects are 8x16 rather than 8x8
+	gbLCDCBGTileMap uint8 = 0x1 << 3 // background uses the 0x9C00 tile map
+	gbLCDCTileData  uint8 = 0x1 << 4 // tiles are indexed unsigned from 0x8000
+	gbLCDCWinEnable uint8 = 0x1 << 5
+	gbLCDCWinMap    uint8 = 0x1 << 6 // window uses the 0x9C00 tile map
+
+	gbWXOffset = 7
+	gbWXMax    = 166 // the window is off the right of the screen beyond this
+
+	gbAddrTileMap0  uint32 = 0x9800
+	gbAddrTileMap1  uint32 = 0x9C00
+	gbAddrTileData0 uint32 = 0x8000 // base for unsigned tile indices
+	gbAddrTileData1 uint32 = 0x9000 // base for signed tile indices
+	gbTileBytes            = 16     // 8x8 pixels, 2 bits per pixel
+	gbTileMapWidth         = 32     // tiles per tile map row
+
+	gbBGAttrPalette  uint8 = 0x7      // 0b00000111
+	gbBGAttrBank     uint8 = 0x1 << 3 // tile data comes from VRAM bank 1
+	gbBGAttrXFlip    uint8 = 0x1 << 5
+	gbBGAttrYFlip    uint8 = 0x1 << 6
+	gbBGAttrPriority uint8 = 0x1 << 7
+
+	gbOAMEntries   = 40
+	gbOAMEntrySize = 4 // y, x, tile index, attributes
+	gbOBJHeight    = 8
+	gbOBJsPerLine  = 10 // objects the PPU selects for each scanline
+	gbOBJOffsetY   = 16 // objects at y=16, x=8 sit in the top left
+	gbOBJOffsetX   = 8
+
+	// Object attributes use the same bits as background attributes for their
+	// CGB palette, data bank and flips.
+	gbOBJAttrPalette    uint8 = 0x7      // 0b00000111
+	gbOBJAttrDMGPalette uint8 = 0x1 << 4 // object uses OBP1 rather than OBP0
+)
+
+// gbShade maps a colour index through a DMG palette register, which holds
+// the shade of each colour in two bits, starting with colour 0 in the lowest
+// bits.
+func gbShade(palette, color uint8) uint8 {
+	return palette >> (color * 2) & 0x3
+}
+
+// renderLine draws the current scanline into the framebuffer as colour
+// indices from 0 to 3, into the mapped framebuffer as shades through the DMG
+// palettes, and into the colour framebuffer through the CGB palettes if the
+// model has them.
+func (p *gbPPU) renderLine() error {
+	lcdc, err := p.mem.read(gbAddrLCDC)
+	if err != nil {
+		return err
+	}
+	scy, err := p.mem.read(gbAddrSCY)
+	if err != nil {
+		return err
+	}
+	scx, err := p.mem.read(gbAddrSCX)
+	if err != nil {
+		return err
+	}
+	bgp, err := p.mem.read(gbAddrBGP)
+	if err != nil {
+		return err
+	}
+	wy, err := p.mem.read(gbAddrWY)
+	if err != nil {
+		return err
+	}
+	wx, err := p.mem.read(gbAddrWX)
+	if err != nil {
+		return err
+	}
+
+	line := p.frame[p.ly*gbScreenWidth : (p.ly+1)*gbScreenWidth]
+	shades := p.shades[p.ly*gbScreenWidth : (p.ly+1)*gbScreenWidth]
+	colorLine := p.colorFrame[p.ly*gbScreenWidth : (p.ly+1)*gbScreenWidth]
+
+	// On the CGB, LCDC bit 0 controls priority rather than visibility.
+	if !p.cgb && lcdc&gbLCDCBGEnable == 0 {
+		for x := range line {
+			line[x], shades[x] = 0, 0
+		}
+		return nil
+	}
+
+	tileMap := gbAddrTileMap0
+	if lcdc&gbLCDCBGTileMap != 0 {
+		tileMap = gbAddrTileMap1
+	}
+	winMap := gbAddrTileMap0
+	if lcdc&gbLCDCWinMap != 0 {
+		winMap = gbAddrTileMap1
+	}
+
+	// The window covers the background from WX-7 rightwards, on the lines
+	// from WY downwards.
+	winLeft := gbScreenWidth
+	if lcdc&gbLCDCWinEnable != 0 && p.ly >= int(wy) && wx <= gbWXMax {
+		winLeft = int(wx) - gbWXOffset
+	}
+
+	for x := range line {
+		mapX, mapY := uint8(x)+scx, uint8(p.ly)+scy
+		mapAddr := tileMap + uint32(mapY/8)*gbTileMapWidth + uint32(mapX/8)
+		if x >= winLeft {
+			mapX, mapY = uint8(x-winLeft), uint8(p.wly)
+			mapAddr = winMap + uint32(mapY/8)*gbTileMapWidth + uint32(mapX/8)
+		}
+
+		// Bank 1 holds the attributes of the tile at the same map entry.
+		var attrs uint8
+		if p.cgb {
+			attrs = p.vramByte(1, mapAddr)
+		}
+		line[x] = p.tilePixel(lcdc, p.vramByte(0, mapAddr), attrs, mapX%8, mapY%8)
+		shades[x] = gbShade(bgp, line[x])
+
+		if p.bgPalettes != nil {
+			colorLine[x] = p.bgPalettes.color(attrs&gbBGAttrPalette, line[x])
+		}
+	}
+
+	// The window keeps its own line counter, which only moves on lines it's
+	// drawn on.
+	if winLeft < gbScreenWidth {
+		p.wly++
+	}
+
+	if lcdc&gbLCDCOBJEnable != 0 {
+		return p.renderObjects(lcdc, line, shades, colorLine)
+	}
+
+	return nil
+}
+
+// renderObjects draws the objects overlapping the current scanline over the
+// top of it. On the CGB, objects earlier in OAM are drawn over later ones,
+// whereas on the DMG objects further left are drawn on top, with OAM order
+// breaking ties. Objects with the priority attribute set are only drawn over
+// background colour 0. Only the first 10 objects in OAM that overlap the
+// scanline are drawn.
+func (p *gbPPU) renderObjects(lcdc uint8, line, shades []uint8, colorLine []uint16) error {
+	oam, err := p.oam.ReadSlice(gbAddrOAM, gbOAMEntries*gbOAMEntrySize)
+	if err != nil {
+		return err
+	}
+	obp0, err := p.mem.read(gbAddrOBP0)
+	if err != nil {
+		return err
+	}
+	obp1, err := p.mem.read(gbAddrOBP1)
+	if err != nil {
+		return err
+	}
+
+	// The frontmost object with an opaque pixel owns it, even if it ends up
+	// hidden behind the background.
+	bg := append([]uint8(nil), line...)
+	var owned [gbScreenWidth]bool
+
+	height := gbOBJHeight
+	if lcdc&gbLCDCOBJSize != 0 {
+		height *= 2
+	}
+
+	for _, entry := range p.selectObjects(oam, height) {
+		y := p.ly - (int(entry[0]) - gbOBJOffsetY)
+		attrs := entry[3]
+		if !p.cgb {
+			attrs &^= gbBGAttrBank
+		}
+		obp := obp0
+		if attrs&gbOBJAttrDMGPalette != 0 {
+			obp = obp1
+		}
+
+		// Tall objects are made of an even tile on top of an odd one, and
+		// flip as a whole.
+		tile := entry[2]
+		if height > gbOBJHeight {
+			tile &^= 0x1
+		}
+		if attrs&gbBGAttrYFlip != 0 {
+			y = height - 1 - y
+			attrs &^= gbBGAttrYFlip
+		}
+		tile += uint8(y / gbOBJHeight)
+		y %= gbOBJHeight
+
+		left := int(entry[1]) - gbOBJOffsetX
+		for x := 0; x < 8; x++ {
+			sx := left + x
+			if sx < 0 || sx >= gbScreenWidth || owned[sx] {
+				continue
+			}
+
+			// Objects always use unsigned tile indices, and colour 0 is
+			// transparent.
+			color := p.tilePixel(gbLCDCTileData, tile, attrs, uint8(x), uint8(y))
+			if color == 0 {
+				continue
+			}
+			owned[sx] = true
+			if attrs&gbBGAttrPriority != 0 && bg[sx] != 0 {
+				continue
+			}
+
+			line[sx] = color
+			shades[sx] = gbShade(obp, color)
+			if p.objPalettes != nil {
+				colorLine[sx] = p.objPalettes.color(attrs&gbOBJAttrPalette, color)
+			}
+		}
+	}
+
+	return nil
+}
+
+// selectObjects returns the OAM entries of the first 10 objects in OAM that
+// overlap the current scanline, frontmost first. Objects count towards the
+// limit even if they're off the sides of the screen.
+func (p *gbPPU) selectObjects(oam []uint8, height int) [][]uint8 {
+	selected := make([][]uint8, 0, gbOBJsPerLine)
+	for i := 0; i < gbOAMEntries && len(selected) < gbOBJsPerLine; i++ {
+		entry := oam[i*gbOAMEntrySize : (i+1)*gbOAMEntrySize]
+		if y := p.ly - (int(entry[0]) - gbOBJOffsetY); y >= 0 && y < height {
+			selected = append(selected, entry)
+		}
+	}
+	if !p.cgb {
+		sort.SliceStable(selected, func(i, j int) bool {
+			return selected[i][1] < selected[j][1]
+		})
+	}
+
+	return selected
+}
+
+// tilePixel returns the colour index of the pixel at (x, y) within the given
+// tile, which has the given background or object attributes.
+func (p *gbPPU) tilePixel(lcdc, index, attrs, x, y uint8) uint8 {
+	addr := tileAddr(lcdc, index)
+	if attrs&gbBGAttrXFlip != 0 {
+		x = 7 - x
+	}
+	if attrs&gbBGAttrYFlip != 0 {
+		y = 7 - y
+	}
+
+	bank := 0
+	if attrs&gbBGAttrBank != 0 {
+		bank = 1
+	}
+
+	// Each row is two bytes: the low bits of every pixel, then the high bits,
+	// with the leftmost pixel in bit 7.
+	lo := p.vramByte(bank, addr+uint32(y)*2)
+	hi := p.vramByte(bank, addr+uint32(y)*2+1)
+	bit := 7 - x
+
+	return (hi>>bit&1)<<1 | lo>>bit&1
+}
+
+// tileAddr returns the address of the given tile's data, which depends on
+// the addressing mode selected by LCDC.
+func tileAddr(lcdc, index uint8) uint32 {
+	if lcdc&gbLCDCTileData == 0 {
+		return uint32(int32(gbAddrTileData1) + int32(int8(index))*gbTileBytes)
+	}
+
+	return gbAddrTileData0 + uint32(index)*gbTileBytes
+}
@@ -0,0 +1,184 @@
+package gb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMappedFramebuffer tests mapping DMG colour indices to shades through
+// the background and object palettes.
+func TestMappedFramebuffer(t *testing.T) {
+	g := prepareBackground(t, DMG)
+	assert.NoError(t, g.bus.poke(gbAddrLCDC, 0x93))
+	assert.NoError(t, g.bus.poke(gbAddrBGP, 0x1B))  // 0b00011011, inverted
+	assert.NoError(t, g.bus.poke(gbAddrOBP1, 0x08)) // colour 1 is shade 2
+	fillTile(t, g, 0x8020, 1)
+
+	// An object using OBP1 sits 16 pixels from the left of the screen.
+	assert.NoError(t, pokeN(g.bus.mem, gbAddrOAM, []uint8{16, 24, 0x02, gbOBJAttrDMGPalette}))
+	runFrames(t, g, 1)
+
+	shades := g.ppu.(*gbPPU).MappedFramebuffer()
+	assert.Equal(t, uint8(0), g.GetFramebuffer()[0])
+	assert.Equal(t, uint8(3), shades[0])
+	assert.Equal(t, uint8(2), shades[16])
+}
+
+// TestRenderObjectPriority tests that objects with the priority attribute set
+// are hidden behind background colours other than 0.
+func TestRenderObjectPriority(t *testing.T) {
+	g := prepareBackground(t, DMG)
+	assert.NoError(t, g.bus.poke(gbAddrLCDC, 0x93))
+	fillTile(t, g, 0x8010, 1)
+	fillTile(t, g, 0x8020, 2)
+
+	// Both objects are behind the background, but only the first overlaps
+	// tile 1 in the top left.
+	assert.NoError(t, pokeN(g.bus.mem, gbAddrOAM, []uint8{
+		16, 8, 0x02, gbBGAttrPriority,
+		16, 16, 0x02, gbBGAttrPriority,
+	}))
+	runFrames(t, g, 1)
+
+	fb := g.GetFramebuffer()
+	assert.Equal(t, uint8(1), fb[0])
+	assert.Equal(t, uint8(1), fb[7])
+	assert.Equal(t, uint8(2), fb[8])
+}
+
+// TestRenderObjectLimit tests that only the first 10 objects in OAM on a
+// scanline are drawn, regardless of their positions.
+func TestRenderObjectLimit(t *testing.T) {
+	g := prepareBackground(t, DMG)
+	assert.NoError(t, g.bus.poke(gbAddrLCDC, 0x93))
+	fillTile(t, g, 0x8020, 2)
+
+	// Objects later in OAM sit further left, so the 5 that miss out are the
+	// leftmost ones.
+	const n = 15
+	for i := 0; i < n; i++ {
+		entry := []uint8{16, uint8(gbOBJOffsetX + (n-1-i)*8), 0x02, 0x00}
+		assert.NoError(t, pokeN(g.bus.mem, gbAddrOAM+uint32(i*gbOAMEntrySize), entry))
+	}
+	runFrames(t, g, 1)
+
+	fb := g.GetFramebuffer()
+	for x := 0; x < n*8; x++ {
+		if x < (n-gbOBJsPerLine)*8 {
+			assert.Equal(t, uint8(0), fb[x], "x=%d", x)
+		} else {
+			assert.Equal(t, uint8(2), fb[x], "x=%d", x)
+		}
+	}
+}
+
+// TestRenderTallObjects tests drawing 8x16 objects, which are made of two
+// consecutive tiles.
+func TestRenderTallObjects(t *testing.T) {
+	g := prepareBackground(t, DMG)
+	assert.NoError(t, g.bus.poke(gbAddrLCDC, 0x97))
+	fillTile(t, g, 0x8020, 1)
+	fillTile(t, g, 0x8030, 2)
+
+	// The low bit of the tile index is ignored, and the second object is
+	// flipped upside down.
+	assert.NoError(t, pokeN(g.bus.mem, gbAddrOAM, []uint8{
+		20, 8, 0x03, 0x00,
+		20, 16, 0x02, gbBGAttrYFlip,
+	}))
+	runFrames(t, g, 1)
+
+	fb := g.GetFramebuffer()
+	assert.Equal(t, uint8(0), fb[3*gbScreenWidth])
+	assert.Equal(t, uint8(1), fb[4*gbScreenWidth])
+	assert.Equal(t, uint8(1), fb[11*gbScreenWidth])
+	assert.Equal(t, uint8(2), fb[12*gbScreenWidth])
+	assert.Equal(t, uint8(2), fb[19*gbScreenWidth])
+	assert.Equal(t, uint8(0), fb[20*gbScreenWidth])
+
+	assert.Equal(t, uint8(2), fb[4*gbScreenWidth+8])
+	assert.Equal(t, uint8(1), fb[12*gbScreenWidth+8])
+}
+
+// TestRenderWindowLineCounter tests that the window carries on from the line
+// it stopped at when it's re-enabled mid-frame.
+func TestRenderWindowLineCounter(t *testing.T) {
+	g := prepareBackground(t, DMG)
+	assert.NoError(t, g.bus.poke(gbAddrLCDC, 0xF1))
+	assert.NoError(t, g.bus.poke(gbAddrWY, 0))
+	assert.NoError(t, g.bus.poke(gbAddrWX, gbWXOffset))
+	fillTile(t, g, 0x8010, 1)
+	fillTile(t, g, 0x8020, 2)
+
+	// The window's first row of tiles is tile 1, and its second is tile 2.
+	for x := uint32(0); x < gbScreenWidth/8; x++ {
+		assert.NoError(t, g.bus.poke(gbAddrTileMap1+x, 0x01))
+		assert.NoError(t, g.bus.poke(gbAddrTileMap1+gbTileMapWidth+x, 0x02))
+	}
+
+	// Draw 4 lines of the window, then hide it until line 100.
+	g.OnScanline(func(line int, pixels []uint8) {
+		switch line {
+		case 3:
+			assert.NoError(t, g.bus.poke(gbAddrLCDC, 0xD1))
+		case 99:
+			assert.NoError(t, g.bus.poke(gbAddrLCDC, 0xF1))
+		}
+	})
+	runFrames(t, g, 1)
+
+	fb := g.GetFramebuffer()
+	assert.Equal(t, uint8(1), fb[3*gbScreenWidth+8])
+	assert.Equal(t, uint8(0), fb[4*gbScreenWidth+8])
+	assert.Equal(t, uint8(1), fb[100*gbScreenWidth+8])
+	assert.Equal(t, uint8(1), fb[103*gbScreenWidth+8])
+	assert.Equal(t, uint8(2), fb[104*gbScreenWidth+8])
+	assert.Equal(t, 0, g.ppu.snapshot().WLY)
+}
+
+// TestTransferScrollPenalty tests that fine scrolling lengthens pixel
+// transfer by a dot for each pixel discarded.
+func TestTransferScrollPenalty(t *testing.T) {
+	hblankDot := func(scx uint8) int {
+		g := NewGameboy()
+		assert.NoError(t, g.bus.poke(gbAddrSCX, scx))
+		for g.ppu.Mode() != gbPPUModeHBlank {
+			if !assert.NoError(t, g.ppu.Tick(1)) {
+				return 0
+			}
+		}
+		return g.ppu.snapshot().Dots
+	}
+
+	assert.Equal(t, gbOAMScanDots+gbTransferDots, hblankDot(0))
+	assert.Equal(t, hblankDot(0)+4, hblankDot(4))
+	assert.Equal(t, hblankDot(0)+4, hblankDot(12))
+}
+
+// TestRenderObjectOrder tests which of two overlapping objects is drawn on
+// top for each model.
+func TestRenderObjectOrder(t *testing.T) {
+	testFn := func(model GameboyModel, x0, x1 uint8, expected uint8) func(*testing.T) {
+		return func(t *testing.T) {
+			g := prepareBackground(t, model)
+			assert.NoError(t, g.bus.poke(gbAddrLCDC, 0x93))
+			fillTile(t, g, 0x8020, 1)
+			fillTile(t, g, 0x8030, 2)
+
+			// Both objects cover x=20, where object 0 is colour 1 and
+			// object 1 is colour 2.
+			assert.NoError(t, pokeN(g.bus.mem, gbAddrOAM, []uint8{
+				16, x0, 0x02, 0x00,
+				16, x1, 0x03, 0x00,
+			}))
+			runFrames(t, g, 1)
+			assert.Equal(t, expected, g.GetFramebuffer()[20])
+		}
+	}
+
+	t.Run("DMG same x", testFn(DMG, 24, 24, 1))
+	t.Run("CGB same x", testFn(CGB, 24, 24, 1))
+	t.Run("DMG lower x", testFn(DMG, 26, 24, 2))
+	t.Run("CGB lower x", testFn(CGB, 26, 24, 1))
+}
@@ -0,0 +1,68 @@
+package gb
+
+// AudioResampler converts a stream of samples from one rate to another by
+// linear interpolation, such as from the APU's native rate of one sample per
+// quartz-cycle to the 44100Hz or 48000Hz a host's audio system expects. The
+// stream can be fed in chunks of any size.
+// TODO(guy): Filter out frequencies above the output's Nyquist limit first.
+type AudioResampler struct {
+	step float64 // input samples per output sample
+
+	// pos is the position of the next output sample relative to the start of
+	// the next chunk of input. It lies between -1 and 0 when the sample falls
+	// between the last chunk and the next one.
+	pos  float64
+	last float32 // last sample of the previous chunk
+}
+
+// NewAudioResampler returns a resampler from inputRate to outputRate, both
+// in samples per second. Rates below 1 are raised to 1.
+func NewAudioResampler(inputRate, outputRate int) *AudioResampler {
+	if inputRate < 1 {
+		inputRate = 1
+	}
+	if outputRate < 1 {
+		outputRate = 1
+	}
+
+	return &AudioResampler{
+		step: float64(inputRate) / float64(outputRate),
+	}
+}
+
+// Resample returns the output samples that fall within the given chunk of
+// input. Output samples that need input beyond the end of the chunk are held
+// back until the next call.
+func (r *AudioResampler) Resample(input []float32) []float32 {
+	if len(input) == 0 {
+		return nil
+	}
+
+	sample := func(i int) float32 {
+		if i < 0 {
+			return r.last
+		}
+		return input[i]
+	}
+
+	out := make([]float32, 0, int(float64(len(input))/r.step)+1)
+	for {
+		i := int(r.pos)
+		if r.pos < 0 {
+			i = -1
+		}
+		if i+1 >= len(input) {
+			break
+		}
+
+		frac := float32(r.pos - float64(i))
+		a, b := sample(i), sample(i+1)
+		out = append(out, a+(b-a)*frac)
+		r.pos += r.step
+	}
+
+	r.pos -= float64(len(input))
+	r.last = input[len(input)-1]
+
+	return out
+}
@@ -0,0 +1,65 @@
+package gb
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestAudioResampler tests that a sine wave keeps its frequency when it's
+// resampled from the native rate to 44100Hz in chunks.
+func TestAudioResampler(t *testing.T) {
+	const (
+		freq       = 1000
+		outputRate = 44100
+	)
+
+	input := make([]float32, gbClockHz/10)
+	for i := range input {
+		input[i] = float32(math.Sin(2 * math.Pi * freq * float64(i) / gbClockHz))
+	}
+
+	r := NewAudioResampler(gbClockHz, outputRate)
+	// Feed the input a frame at a time.
+	var output []float32
+	for chunk := 0; chunk < len(input); chunk += gbCyclesPerFrame {
+		end := chunk + gbCyclesPerFrame
+		if end > len(input) {
+			end = len(input)
+		}
+		output = append(output, r.Resample(input[chunk:end])...)
+	}
+	assert.InDelta(t, outputRate/10, len(output), 1)
+
+	// Every output sample lies on the original wave.
+	for i, val := range output {
+		expected := math.Sin(2 * math.Pi * freq * float64(i) / outputRate)
+		if !assert.InDelta(t, expected, float64(val), 1e-3, "sample %d", i) {
+			return
+		}
+	}
+
+	// Count the rising zero crossings to recover the frequency.
+	crossings := 0
+	for i := 1; i < len(output); i++ {
+		if output[i-1] < 0 && output[i] >= 0 {
+			crossings++
+		}
+	}
+	assert.InDelta(t, freq/10, crossings, 1)
+}
+
+// TestAudioResamplerBadRates tests that rates below 1 are raised to 1 rather
+// than leaving the resampler stuck.
+func TestAudioResamplerBadRates(t *testing.T) {
+	input := []float32{0, 1, 2, 3}
+	for _, rates := range [][2]int{{4, 0}, {4, -1}, {0, 4}} {
+		r := NewAudioResampler(rates[0], rates[1])
+		assert.True(t, len(r.Resample(input)) <= 4*len(input), rates)
+	}
+
+	assert.Equal(t, gbAudioSampleRate, NewGameboy(WithAudioSampleRate(0)).audioRate)
+	assert.Equal(t, gbAudioSampleRate, NewGameboy(WithAudioSampleRate(-1)).audioRate)
+	assert.Equal(t, 48000, NewGameboy(WithAudioSampleRate(48000)).audioRate)
+}
@@ -0,0 +1,79 @@
+package gb
+
+import "errors"
+
+var (
+	gbErrRewindDisabled     = errors.New("gbRewind: rewind hasn't been enabled")
+	gbErrRewindOutOfHistory = errors.New("gbRewind: not enough snapshots to rewind that far")
+)
+
+// gbRewindBuffer is a ring buffer of save states. Once it's full, pushing a
+// new state overwrites the oldest one.
+type gbRewindBuffer struct {
+	states [][]byte
+	head   int // index of the next slot to write
+	size   int // number of valid states in the buffer
+}
+
+func newGBRewindBuffer(capacity int) *gbRewindBuffer {
+	if capacity < 1 {
+		capacity = 1
+	}
+
+	return &gbRewindBuffer{
+		states: make([][]byte, capacity),
+	}
+}
+
+func (b *gbRewindBuffer) push(state []byte) {
+	if len(b.states) == 0 {
+		return
+	}
+
+	b.states[b.head] = state
+	b.head = (b.head + 1) % len(b.states)
+	if b.size < len(b.states) {
+		b.size++
+	}
+}
+
+func (b *gbRewindBuffer) pop() []byte {
+	if b.size == 0 {
+		return nil
+	}
+
+	b.head = (b.head - 1 + len(b.states)) % len(b.states)
+	b.size--
+
+	state := b.states[b.head]
+	b.states[b.head] = nil
+	return state
+}
+
+// EnableRewind makes the gameboy snapshot its state at the start of every
+// frame, keeping the most recent capacity snapshots around for Rewind.
+// Capacities below 1 are raised to 1.
+func (g *Gameboy) EnableRewind(capacity int) {
+	g.rewind = newGBRewindBuffer(capacity)
+}
+
+// Rewind restores the gameboy to the state it was in the given number of
+// frames ago. The snapshots for the rewound frames are discarded.
+func (g *Gameboy) Rewind(frames int) error {
+	if g.rewind == nil {
+		return gbErrRewindDisabled
+	}
+	if frames <= 0 {
+		return nil
+	}
+	if frames > g.rewind.size {
+		return gbErrRewindOutOfHistory
+	}
+
+	var state []byte
+	for i := 0; i < frames; i++ {
+		state = g.rewind.pop()
+	}
+
+	return g.LoadState(state)
+}
@@ -0,0 +1,50 @@
+package gb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRewind tests that rewinding restores the state from the given number of
+// frames ago.
+func TestRewind(t *testing.T) {
+	g := prepareGameboy(t, testProgram)
+	g.EnableRewind(10)
+
+	runFrames(t, g, 10)
+	expected, err := g.SaveState()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	runFrames(t, g, 5)
+	assert.NoError(t, g.Rewind(5))
+
+	actual, err := g.SaveState()
+	assert.NoError(t, err)
+	assert.Equal(t, expected, actual)
+}
+
+// TestRewindLimits tests rewinding without history or past the capacity.
+func TestRewindLimits(t *testing.T) {
+	g := prepareGameboy(t, testProgram)
+	assert.Equal(t, gbErrRewindDisabled, g.Rewind(1))
+
+	g.EnableRewind(3)
+	runFrames(t, g, 5)
+	assert.Equal(t, gbErrRewindOutOfHistory, g.Rewind(4))
+	assert.NoError(t, g.Rewind(3))
+	assert.Equal(t, gbErrRewindOutOfHistory, g.Rewind(1))
+}
+
+// TestRewindCapacity tests that capacities below 1 keep the last frame.
+func TestRewindCapacity(t *testing.T) {
+	for _, capacity := range []int{0, -1} {
+		g := prepareGameboy(t, testProgram)
+		g.EnableRewind(capacity)
+		runFrames(t, g, 3)
+		assert.Equal(t, gbErrRewindOutOfHistory, g.Rewind(2))
+		assert.NoError(t, g.Rewind(1))
+	}
+}
@@ -0,0 +1,81 @@
+package gb
+
+import "sync"
+
+// SafeGameboy wraps a Gameboy so that it can be used from multiple
+// goroutines, such as an emulation loop and a UI thread.
+type SafeGameboy struct {
+	mu sync.RWMutex
+	g  *Gameboy
+}
+
+// NewSafeGameboy wraps the given gameboy, which shouldn't be used directly
+// afterwards.
+func NewSafeGameboy(g *Gameboy) *SafeGameboy {
+	return &SafeGameboy{g: g}
+}
+
+// Step runs Gameboy.Step while holding the lock.
+func (s *SafeGameboy) Step() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.g.Step()
+}
+
+// RunFrame runs a frame, holding the lock for all of it.
+func (s *SafeGameboy) RunFrame() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.g.RunFrame()
+}
+
+// LoadState loads a save state taken with SaveState.
+func (s *SafeGameboy) LoadState(data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.g.LoadState(data)
+}
+
+// SetInputState sets which buttons and directions are pressed.
+func (s *SafeGameboy) SetInputState(buttons, directions uint8) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.g.SetInputState(buttons, directions)
+}
+
+// SaveState returns a save state of the gameboy.
+func (s *SafeGameboy) SaveState() ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.g.SaveState()
+}
+
+// DumpRegisters returns the CPU's registers formatted for debugging.
+func (s *SafeGameboy) DumpRegisters() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.g.DumpRegisters()
+}
+
+// GetFramebuffer returns a copy of the last frame drawn, as colour indices.
+func (s *SafeGameboy) GetFramebuffer() []uint8 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.g.GetFramebuffer()
+}
+
+// GetColorFramebuffer returns a copy of the last frame drawn, in BGR555
+// colour.
+func (s *SafeGameboy) GetColorFramebuffer() []uint16 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.g.GetColorFramebuffer()
+}
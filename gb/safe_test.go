@@ -0,0 +1,38 @@
+package gb
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSafeGameboy tests running frames while reading state from another
+// goroutine. Run with -race to check for data races.
+func TestSafeGameboy(t *testing.T) {
+	s := NewSafeGameboy(prepareGameboy(t, testProgram))
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 5; i++ {
+			assert.NoError(t, s.RunFrame())
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 5; i++ {
+			assert.NotEmpty(t, s.DumpRegisters())
+			_, err := s.SaveState()
+			assert.NoError(t, err)
+			assert.Len(t, s.GetFramebuffer(), gbScreenWidth*gbScreenHeight)
+		}
+	}()
+	wg.Wait()
+
+	state, err := s.SaveState()
+	assert.NoError(t, err)
+	assert.NoError(t, s.Step())
+	assert.NoError(t, s.LoadState(state))
+}
@@ -0,0 +1,46 @@
+package gb
+
+const (
+	gbFrameSequencerCycles = 8192 // quartz-cycles per step, so 512Hz
+	gbFrameSequencerSteps  = 8
+)
+
+// frameSequencer clocks the APU's slower units off a 512Hz step counter. The
+// length counters are clocked on even steps (256Hz), the frequency sweep on
+// steps 2 and 6 (128Hz) and the volume envelopes on step 7 (64Hz).
+type frameSequencer struct {
+	step   int // the next step to fire
+	cycles int // quartz-cycles since the last step
+
+	// Callbacks for the units being clocked, any of which can be nil.
+	length, sweep, envelope func()
+}
+
+func newFrameSequencer() *frameSequencer {
+	return &frameSequencer{}
+}
+
+// Tick moves the frame sequencer forward by the given number of quartz-cycles,
+// firing the callbacks for any steps it reaches.
+func (s *frameSequencer) Tick(tcycles int) {
+	s.cycles += tcycles
+	for s.cycles >= gbFrameSequencerCycles {
+		s.cycles -= gbFrameSequencerCycles
+		s.fire()
+	}
+}
+
+// fire clocks the units for the current step and moves on to the next.
+func (s *frameSequencer) fire() {
+	if s.step%2 == 0 && s.length != nil {
+		s.length()
+	}
+	if (s.step == 2 || s.step == 6) && s.sweep != nil {
+		s.sweep()
+	}
+	if s.step == 7 && s.envelope != nil {
+		s.envelope()
+	}
+
+	s.step = (s.step + 1) % gbFrameSequencerSteps
+}
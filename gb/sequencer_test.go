@@ -0,0 +1,38 @@
+package gb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestFrameSequencer tests that each step fires once per 512Hz period and
+// clocks the right units.
+func TestFrameSequencer(t *testing.T) {
+	s := newFrameSequencer()
+
+	var fired []string
+	s.length = func() { fired = append(fired, "length") }
+	s.sweep = func() { fired = append(fired, "sweep") }
+	s.envelope = func() { fired = append(fired, "envelope") }
+
+	expected := [gbFrameSequencerSteps][]string{
+		{"length"}, nil, {"length", "sweep"}, nil,
+		{"length"}, nil, {"length", "sweep"}, {"envelope"},
+	}
+
+	for step := 0; step < gbFrameSequencerSteps; step++ {
+		fired = nil
+		s.Tick(gbFrameSequencerCycles - 1)
+		assert.Empty(t, fired)
+
+		s.Tick(1)
+		assert.Equal(t, expected[step], fired, "step %d", step)
+		assert.Equal(t, (step+1)%gbFrameSequencerSteps, s.step)
+	}
+
+	// Large ticks fire every step they pass.
+	fired = nil
+	s.Tick(gbFrameSequencerCycles * gbFrameSequencerSteps)
+	assert.Len(t, fired, 4+2+1)
+}
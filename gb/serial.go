@@ -0,0 +1,104 @@
+package gb
+
+import "errors"
+
+const (
+	gbAddrSB uint32 = 0xFF01 // serial transfer data
+	gbAddrSC uint32 = 0xFF02 // serial transfer control
+
+	gbSCInternalClock uint8 = 0x1 << 0
+	gbSCTransfer      uint8 = 0x1 << 7
+	gbSCUnusedBits    uint8 = 0x7E // 0b01111110
+
+	gbInterruptSerial uint8 = 0x1 << 3
+)
+
+var (
+	gbErrSerialAddress = errors.New("gbSerial: address isn't a serial register")
+)
+
+// SerialHandler is a device plugged into the other end of the link cable.
+type SerialHandler interface {
+	// Transfer is called with each byte the gameboy sends, and returns the
+	// byte the device sends back in exchange.
+	Transfer(out uint8) uint8
+}
+
+// SerialHandlerFunc adapts a function into a SerialHandler.
+type SerialHandlerFunc func(out uint8) uint8
+
+func (f SerialHandlerFunc) Transfer(out uint8) uint8 {
+	return f(out)
+}
+
+// gbSerial backs the serial port registers. The gameboy starts a transfer by
+// setting bit 7 of SC, and when it's driving the clock, the byte in SB is
+// swapped with the attached device's. With nothing attached, 0xFF is
+// received.
+// TODO(guy): Transfers complete instantly, rather than after 8 serial clocks.
+type gbSerial struct {
+	mem     ram // for requesting interrupts
+	handler SerialHandler
+
+	sb, sc uint8
+}
+
+func newGBSerial(mem ram) *gbSerial {
+	return &gbSerial{mem: mem}
+}
+
+func (s *gbSerial) poke(addr uint32, val uint8) error {
+	switch addr {
+	case gbAddrSB:
+		s.sb = val
+		return nil
+
+	case gbAddrSC:
+		s.sc = val &^ gbSCUnusedBits
+		if s.sc&gbSCTransfer == 0 || s.sc&gbSCInternalClock == 0 {
+			return nil
+		}
+		return s.transfer()
+	}
+
+	return gbErrSerialAddress
+}
+
+func (s *gbSerial) read(addr uint32) (uint8, error) {
+	switch addr {
+	case gbAddrSB:
+		return s.sb, nil
+
+	case gbAddrSC:
+		return gbSCUnusedBits | s.sc, nil
+	}
+
+	return 0, gbErrSerialAddress
+}
+
+func (s *gbSerial) ReadSlice(addr, n uint32) ([]uint8, error) {
+	return readN(s, addr, n)
+}
+
+// transfer exchanges SB with the attached device and requests the serial
+// interrupt.
+func (s *gbSerial) transfer() error {
+	in := uint8(0xFF)
+	if s.handler != nil {
+		in = s.handler.Transfer(s.sb)
+	}
+	s.sb = in
+	s.sc &^= gbSCTransfer
+
+	flags, err := s.mem.read(gbAddrIF)
+	if err != nil {
+		return err
+	}
+	return s.mem.poke(gbAddrIF, flags|gbInterruptSerial)
+}
+
+// SetSerialHandler plugs a device into the link cable port, or unplugs it if
+// the handler is nil.
+func (g *Gameboy) SetSerialHandler(h SerialHandler) {
+	g.serial.handler = h
+}
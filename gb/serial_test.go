@@ -0,0 +1,50 @@
+package gb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSerialTransfer tests exchanging a byte with a device on the link cable.
+func TestSerialTransfer(t *testing.T) {
+	g := NewGameboy()
+
+	var sent []uint8
+	g.SetSerialHandler(SerialHandlerFunc(func(b uint8) uint8 {
+		sent = append(sent, b)
+		return 0x42
+	}))
+
+	assert.NoError(t, g.bus.poke(gbAddrSB, 'P'))
+	assert.NoError(t, g.bus.poke(gbAddrSC, gbSCTransfer|gbSCInternalClock))
+	assert.Equal(t, []uint8{'P'}, sent)
+
+	sb, err := g.bus.read(gbAddrSB)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0x42), sb)
+
+	// The transfer completes immediately and requests an interrupt.
+	sc, err := g.bus.read(gbAddrSC)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0x7F), sc)
+	flags, err := g.bus.read(gbAddrIF)
+	assert.NoError(t, err)
+	assert.Equal(t, gbInterruptSerial, flags&gbInterruptSerial)
+}
+
+// TestSerialExternalClock tests that nothing is sent while waiting for the
+// other end of the link cable to drive the clock.
+func TestSerialExternalClock(t *testing.T) {
+	g := NewGameboy()
+
+	called := false
+	g.SetSerialHandler(SerialHandlerFunc(func(b uint8) uint8 {
+		called = true
+		return 0
+	}))
+
+	assert.NoError(t, g.bus.poke(gbAddrSB, 'P'))
+	assert.NoError(t, g.bus.poke(gbAddrSC, gbSCTransfer))
+	assert.False(t, called)
+}
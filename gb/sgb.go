@@ -0,0 +1,142 @@
+package gb
+
+const (
+	gbSGBPacketSize = 16 // bytes in a command packet
+	gbSGBPacketBits = gbSGBPacketSize * 8
+	gbSGBMaxPlayers = 4
+
+	gbSGBCmdMLTPLYR uint8 = 0x11 // set the number of players
+)
+
+// gbSGBPlayerCounts maps the argument of MLTPLYR to the number of players.
+var gbSGBPlayerCounts = [4]int{1, 2, 1, 4}
+
+// sgbController receives command packets sent to the super gameboy through
+// the joypad register. Each bit is a pulse on one of the select lines, with
+// both lines returning high in between. Pulsing both lines low starts a
+// packet, P14 alone sends a 0 and P15 alone sends a 1. Packets are 128 bits,
+// least significant bit of each byte first, followed by a 0 stop bit.
+//
+// With more than one player, the joypad register reads the current player's
+// inputs, and reads the player's ID with both lines high. The next player is
+// chosen each time P15 goes high again.
+// TODO(guy): Nothing acts on the other packets yet, beyond passing them on.
+type sgbController struct {
+	handler func(packet []uint8)
+
+	packet [gbSGBPacketSize]uint8
+	bits   int   // bits received so far, or -1 while waiting for a reset
+	lines  uint8 // select lines at the last write
+
+	players int // number of players enabled by MLTPLYR
+	player  int // current player, from 0
+}
+
+func newSGBController() *sgbController {
+	s := &sgbController{}
+	s.reset()
+
+	return s
+}
+
+// reset returns the controller to its power-on state, keeping the handler.
+func (s *sgbController) reset() {
+	*s = sgbController{
+		handler: s.handler,
+		bits:    -1,
+		lines:   gbP1SelectMask,
+		players: 1,
+	}
+}
+
+// gbSGBState is the serialisable internal state of an sgbController.
+type gbSGBState struct {
+	Packet [gbSGBPacketSize]uint8
+	Bits   int
+	Lines  uint8
+
+	Players, Player int
+}
+
+func (s *sgbController) snapshot() gbSGBState {
+	return gbSGBState{
+		Packet:  s.packet,
+		Bits:    s.bits,
+		Lines:   s.lines,
+		Players: s.players,
+		Player:  s.player,
+	}
+}
+
+func (s *sgbController) restore(st gbSGBState) {
+	s.packet, s.bits, s.lines = st.Packet, st.Bits, st.Lines
+	s.players, s.player = st.Players, st.Player
+}
+
+// PlayerCount returns the number of players the game has asked for.
+func (s *sgbController) PlayerCount() int {
+	return s.players
+}
+
+// write observes the select lines written to the joypad register.
+func (s *sgbController) write(lines uint8) {
+	prev := s.lines
+	s.lines = lines
+	if s.bits < 0 && prev&gbP1SelectButtons == 0 && lines&gbP1SelectButtons != 0 {
+		s.player = (s.player + 1) % s.players
+	}
+	if prev != gbP1SelectMask || lines == gbP1SelectMask {
+		return // not a pulse
+	}
+
+	switch {
+	case lines == 0:
+		s.packet = [gbSGBPacketSize]uint8{}
+		s.bits = 0
+
+	case s.bits < 0:
+		// Stray pulses outside of a packet are ignored.
+
+	case s.bits == gbSGBPacketBits:
+		if lines == gbP1SelectButtons {
+			s.handle()
+		}
+		s.bits = -1
+
+	default:
+		if lines == gbP1SelectDirections {
+			s.packet[s.bits/8] |= 0x1 << (s.bits % 8)
+		}
+		s.bits++
+	}
+}
+
+// handle acts on a complete packet and passes it on to the handler.
+func (s *sgbController) handle() {
+	if s.packet[0]>>3 == gbSGBCmdMLTPLYR {
+		s.players = gbSGBPlayerCounts[s.packet[1]&0x3]
+		s.player = 0
+	}
+
+	if s.handler != nil {
+		s.handler(append([]uint8(nil), s.packet[:]...))
+	}
+}
+
+// OnSGBPacket registers a callback that fires with each command packet the
+// game sends to the super gameboy. It does nothing for other models.
+func (g *Gameboy) OnSGBPacket(fn func(packet []uint8)) {
+	if g.joypad.sgb != nil {
+		g.joypad.sgb.handler = fn
+	}
+}
+
+// PlayerCount returns the number of players a super gameboy game has asked
+// for, which is always 1 for other models.
+func (g *Gameboy) PlayerCount() int {
+	if g.joypad.sgb == nil {
+		return 1
+	}
+
+	return g.joypad.sgb.PlayerCount()
+}
@@ -0,0 +1,153 @@
+package gb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// sendSGBPacket sends a command packet through the joypad register, the way
+// a game talks to the super gameboy.
+func sendSGBPacket(t *testing.T, g *Gameboy, packet []uint8) {
+	pulse := func(lines uint8) {
+		assert.NoError(t, g.bus.poke(gbAddrP1, lines))
+		assert.NoError(t, g.bus.poke(gbAddrP1, gbP1SelectMask))
+	}
+
+	pulse(0x00)
+	for _, b := range packet {
+		for i := 0; i < 8; i++ {
+			if b>>i&0x1 != 0 {
+				pulse(gbP1SelectDirections)
+			} else {
+				pulse(gbP1SelectButtons)
+			}
+		}
+	}
+	pulse(gbP1SelectButtons)
+}
+
+// TestSGBPacket tests receiving a PAL_SGB command packet.
+func TestSGBPacket(t *testing.T) {
+	g := NewGameboy(WithModel(SGB))
+
+	var packets [][]uint8
+	g.OnSGBPacket(func(packet []uint8) { packets = append(packets, packet) })
+
+	// PAL_SGB (0x0A) with a length of 1, selecting palette 0x1FF for each
+	// of the four system palettes, and the ATF 0x05.
+	packet := []uint8{
+		0x0A<<3 | 1, 0xFF, 0x01, 0xFF, 0x01, 0xFF, 0x01, 0xFF,
+		0x01, 0x85, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	}
+	sendSGBPacket(t, g, packet)
+	sendSGBPacket(t, g, packet)
+	assert.Equal(t, [][]uint8{packet, packet}, packets)
+
+	// Ordinary joypad reads in between don't send anything.
+	assert.NoError(t, g.bus.poke(gbAddrP1, gbP1SelectButtons))
+	assert.NoError(t, g.bus.poke(gbAddrP1, gbP1SelectMask))
+	assert.Len(t, packets, 2)
+}
+
+// TestSGBPacketOtherModels tests that only the super gameboy listens for
+// command packets.
+func TestSGBPacketOtherModels(t *testing.T) {
+	g := NewGameboy(WithModel(DMG))
+
+	called := false
+	g.OnSGBPacket(func([]uint8) { called = true })
+	sendSGBPacket(t, g, make([]uint8, gbSGBPacketSize))
+	assert.False(t, called)
+}
+
+// TestSGBMultiplayer tests switching between two players' joypads after a
+// MLTPLYR command.
+func TestSGBMultiplayer(t *testing.T) {
+	g := NewGameboy(WithModel(SGB))
+	g.SetInputState(ButtonA, 0)
+	g.SetPlayerInputState(1, ButtonB, DirectionUp)
+	assert.Equal(t, 1, g.PlayerCount())
+
+	packet := make([]uint8, gbSGBPacketSize)
+	packet[0], packet[1] = gbSGBCmdMLTPLYR<<3|1, 0x01
+	sendSGBPacket(t, g, packet)
+	assert.Equal(t, 2, g.PlayerCount())
+
+	// readPlayer reads the player's ID and inputs the way games do, with
+	// the last write moving on to the next player.
+	readPlayer := func() (id, buttons, directions uint8) {
+		read := func(lines uint8) uint8 {
+			assert.NoError(t, g.bus.poke(gbAddrP1, lines))
+			val, err := g.bus.read(gbAddrP1)
+			assert.NoError(t, err)
+			return ^val & gbP1InputMask
+		}
+
+		id = read(gbP1SelectMask)
+		directions = read(gbP1SelectButtons)
+		buttons = read(gbP1SelectDirections)
+		assert.NoError(t, g.bus.poke(gbAddrP1, gbP1SelectMask))
+		return
+	}
+
+	for i := 0; i < 2; i++ {
+		id, buttons, directions := readPlayer()
+		assert.Equal(t, uint8(0), id)
+		assert.Equal(t, ButtonA, buttons)
+		assert.Equal(t, uint8(0), directions)
+
+		id, buttons, directions = readPlayer()
+		assert.Equal(t, uint8(1), id)
+		assert.Equal(t, ButtonB, buttons)
+		assert.Equal(t, DirectionUp, directions)
+	}
+
+	g.Reset()
+	assert.Equal(t, 1, g.PlayerCount())
+	assert.Equal(t, 1, NewGameboy(WithModel(DMG)).PlayerCount())
+}
+
+// TestSaveLoadStateSGB tests that the super gameboy's players and a packet
+// partway through being sent survive a save state round trip.
+func TestSaveLoadStateSGB(t *testing.T) {
+	g := NewGameboy(WithModel(SGB))
+	var packets [][]uint8
+	g.OnSGBPacket(func(packet []uint8) { packets = append(packets, packet) })
+
+	packet := make([]uint8, gbSGBPacketSize)
+	packet[0], packet[1] = gbSGBCmdMLTPLYR<<3|1, 0x03
+	sendSGBPacket(t, g, packet)
+
+	// Move on to the second player, and start sending the same packet again.
+	// MLTPLYR's first two bits are 1 and then 0.
+	assert.NoError(t, g.bus.poke(gbAddrP1, gbP1SelectDirections))
+	assert.NoError(t, g.bus.poke(gbAddrP1, gbP1SelectMask))
+	for _, lines := range []uint8{0x00, gbP1SelectDirections, gbP1SelectButtons} {
+		assert.NoError(t, g.bus.poke(gbAddrP1, lines))
+		assert.NoError(t, g.bus.poke(gbAddrP1, gbP1SelectMask))
+	}
+	expected := g.joypad.sgb.snapshot()
+	assert.Equal(t, 2, expected.Bits)
+	assert.Equal(t, 1, expected.Player)
+
+	saved, err := g.SaveState()
+	if !assert.NoError(t, err) {
+		return
+	}
+	g.Reset()
+	assert.NoError(t, g.LoadState(saved))
+	assert.Equal(t, expected, g.joypad.sgb.snapshot())
+	assert.Equal(t, 4, g.PlayerCount())
+
+	// The rest of the packet completes the one that was started.
+	for i := 2; i <= gbSGBPacketBits; i++ {
+		lines := gbP1SelectButtons
+		if i < gbSGBPacketBits && packet[i/8]>>(i%8)&0x1 != 0 {
+			lines = gbP1SelectDirections
+		}
+		assert.NoError(t, g.bus.poke(gbAddrP1, lines))
+		assert.NoError(t, g.bus.poke(gbAddrP1, gbP1SelectMask))
+	}
+	assert.Equal(t, [][]uint8{packet, packet}, packets)
+}
@@ -0,0 +1,83 @@
+package gb
+
+import "errors"
+
+const (
+	gbAddrKEY1 uint32 = 0xFF4D // speed switch (CGB only)
+
+	gbKEY1Armed       uint8 = 0x1 << 0
+	gbKEY1DoubleSpeed uint8 = 0x1 << 7
+	gbKEY1UnusedBits  uint8 = 0x7E // 0b01111110
+)
+
+var (
+	gbErrKEY1Address = errors.New("gbKEY1: address isn't the KEY1 register")
+)
+
+// gbKEY1 backs the CGB's KEY1 register. Setting bit 0 arms a speed switch,
+// which the CPU performs the next time it executes STOP. Bit 7 reads back
+// whether the CPU is running at double speed.
+type gbKEY1 struct {
+	cpu *gbCPU
+}
+
+// value returns the contents of the register.
+func (k *gbKEY1) value() uint8 {
+	val := gbKEY1UnusedBits
+	if k.cpu.speedArmed {
+		val |= gbKEY1Armed
+	}
+	if k.cpu.doubleSpeed {
+		val |= gbKEY1DoubleSpeed
+	}
+
+	return val
+}
+
+// restore sets the register to a value previously returned by value,
+// including the read-only speed bit.
+func (k *gbKEY1) restore(val uint8) {
+	k.cpu.speedArmed = val&gbKEY1Armed != 0
+	k.cpu.doubleSpeed = val&gbKEY1DoubleSpeed != 0
+}
+
+func (k *gbKEY1) poke(addr uint32, val uint8) error {
+	if addr != gbAddrKEY1 {
+		return gbErrKEY1Address
+	}
+
+	k.cpu.speedArmed = val&gbKEY1Armed != 0
+	return nil
+}
+
+func (k *gbKEY1) read(addr uint32) (uint8, error) {
+	if addr != gbAddrKEY1 {
+		return 0, gbErrKEY1Address
+	}
+
+	return k.value(), nil
+}
+
+func (k *gbKEY1) ReadSlice(addr, n uint32) ([]uint8, error) {
+	return readN(k, addr, n)
+}
+
+// DoubleSpeed returns true if the gameboy is running in CGB double-speed
+// mode, where the CPU runs at twice the speed of the rest of the hardware.
+func (g *Gameboy) DoubleSpeed() bool {
+	return g.cpu.DoubleSpeed()
+}
+
+// ClockHz returns the frequency of the CPU's clock, which doubles in CGB
+// double-speed mode.
+func (g *Gameboy) ClockHz() uint32 {
+	return g.clockHz
+}
+
+// updateClock sets the CPU's clock frequency from its speed mode.
+func (g *Gameboy) updateClock() {
+	g.clockHz = gbClockHz
+	if g.cpu.DoubleSpeed() {
+		g.clockHz = gbDoubleSpeedClockHz
+	}
+}
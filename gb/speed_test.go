@@ -0,0 +1,79 @@
+package gb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// speedSwitchProgram arms a speed switch and performs it with STOP.
+var speedSwitchProgram = []uint8{
+	0x26, 0xFF, // 0x100: LD H, 0xFF
+	0x2E, 0x4D, // 0x102: LD L, 0x4D
+	0x36, 0x01, // 0x104: LD (HL), 0x01
+	0x10, 0x00, // 0x106: STOP
+	0x00, // 0x108: NOP
+}
+
+// TestDoubleSpeed tests switching the CGB into double-speed mode.
+func TestDoubleSpeed(t *testing.T) {
+	g := NewGameboy(WithModel(CGB))
+	assert.NoError(t, pokeN(g.bus, 0x100, speedSwitchProgram))
+	assert.False(t, g.DoubleSpeed())
+
+	runInstructions(t, g, 3)
+	key1, err := g.bus.read(gbAddrKEY1)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0x7F), key1)
+
+	runInstructions(t, g, 1)
+	assert.True(t, g.DoubleSpeed())
+	key1, err = g.bus.read(gbAddrKEY1)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0xFE), key1)
+
+	// The NOP after STOP takes half as many quartz-cycles.
+	runInstructions(t, g, 1)
+	assert.Equal(t, uint16(0x109), g.cpu.readRegister(gbRegisterPC))
+	assert.Equal(t, 1, g.wait)
+}
+
+// TestDoubleSpeedDMG tests that STOP doesn't switch speed on the DMG.
+func TestDoubleSpeedDMG(t *testing.T) {
+	g := prepareGameboy(t, speedSwitchProgram)
+	runInstructions(t, g, 4)
+	assert.False(t, g.DoubleSpeed())
+}
+
+// TestDoubleSpeedTimer tests that the clock doubles in double-speed mode, and
+// DIV with it.
+func TestDoubleSpeedTimer(t *testing.T) {
+	g := NewGameboy(WithModel(CGB))
+	assert.NoError(t, pokeN(g.bus, 0x100, speedSwitchProgram))
+	assert.Equal(t, uint32(gbClockHz), g.ClockHz())
+
+	// DIV counts up every 256 cycles of the CPU's clock. The CPU is halted
+	// while it's measured, so that it stays in the same speed mode.
+	c := g.cpu.(*gbCPU)
+	divAfter := func(cycles int) uint8 {
+		c.halted = true
+		defer func() { c.halted = false }()
+
+		assert.NoError(t, g.bus.poke(gbAddrDIV, 0x00))
+		for i := 0; i < cycles; i++ {
+			assert.NoError(t, g.Step())
+		}
+		div, err := g.bus.read(gbAddrDIV)
+		assert.NoError(t, err)
+		return div
+	}
+	assert.Equal(t, uint8(4), divAfter(1024))
+
+	runInstructions(t, g, 4)
+	assert.True(t, g.DoubleSpeed())
+	assert.Equal(t, uint32(gbDoubleSpeedClockHz), g.ClockHz())
+	assert.Equal(t, uint8(8), divAfter(1024))
+
+	g.Reset()
+	assert.Equal(t, uint32(gbClockHz), g.ClockHz())
+}
@@ -0,0 +1,161 @@
+package gb
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+)
+
+var (
+	gbErrStateModelMismatch = errors.New("gbState: state was saved by a different gameboy model")
+)
+
+// gbState is a serialisable snapshot of a gameboy. The fields are exported so
+// that encoding/gob can see them.
+type gbState struct {
+	Model GameboyModel
+
+	Registers [gbRegisterPC + 1]uint16 // indexed by non-combined register types
+	CPU       gbCPUState
+	RAM       []uint8
+	VRAM      []uint8
+	WRAM      []uint8 // banked work RAM, if the model has it
+	HDMA      gbHDMAState
+	KEY1      uint8
+
+	BGPalettes  []uint8 // colour palettes, if the model has them
+	OBJPalettes []uint8
+	PPU         gbPPUState
+	APU         gbAPUState
+	Joypad      uint8      // select bits of the P1 register
+	SGB         gbSGBState // packets and players, if the model is a super gameboy
+	Serial      [2]uint8   // SB and SC
+	Timer       gbTimerState
+	DMA         gbOAMDMAState
+	Cart        []uint8 // cartridge state, if one is loaded
+
+	Wait   int
+	Cycles uint64
+	Frames uint64
+}
+
+// gbStateRegisters lists the registers that make up the CPU's state. The
+// combined registers are left out as they alias the 8-bit ones.
+var gbStateRegisters = []gbRegisterType{
+	gbRegisterA, gbRegisterF, gbRegisterB, gbRegisterC, gbRegisterD,
+	gbRegisterE, gbRegisterH, gbRegisterL, gbRegisterSP, gbRegisterPC,
+}
+
+// SaveState serialises the full state of the gameboy so that it can be
+// restored later with LoadState.
+func (g *Gameboy) SaveState() ([]byte, error) {
+	s := gbState{
+		Model:  g.model,
+		RAM:    g.bus.mem.Snapshot(),
+		VRAM:   g.vram.snapshot(),
+		CPU:    g.cpu.snapshot(),
+		PPU:    g.ppu.snapshot(),
+		APU:    g.apu.snapshot(),
+		Joypad: g.joypad.selectBits,
+		Serial: [2]uint8{g.serial.sb, g.serial.sc},
+		Timer:  g.timer.snapshot(),
+		DMA:    g.dma.snapshot(),
+		Wait:   g.wait,
+		Cycles: g.cycles,
+		Frames: g.frames,
+	}
+
+	for _, rt := range gbStateRegisters {
+		s.Registers[rt] = g.cpu.readRegister(rt)
+	}
+	if g.wram != nil {
+		s.WRAM = g.wram.snapshot()
+	}
+	if g.hdma != nil {
+		s.HDMA = g.hdma.snapshot()
+	}
+	if g.key1 != nil {
+		s.KEY1 = g.key1.value()
+	}
+	if g.joypad.sgb != nil {
+		s.SGB = g.joypad.sgb.snapshot()
+	}
+	if g.cart != nil {
+		s.Cart = g.cart.snapshot()
+	}
+	if g.bgPalettes != nil {
+		s.BGPalettes = g.bgPalettes.snapshot()
+		s.OBJPalettes = g.objPalettes.snapshot()
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&s); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// LoadState restores a state previously serialised by SaveState. The state
+// must have been saved by a gameboy of the same model.
+func (g *Gameboy) LoadState(data []byte) error {
+	var s gbState
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&s); err != nil {
+		return err
+	}
+
+	if s.Model != g.model {
+		return gbErrStateModelMismatch
+	}
+
+	if err := g.bus.mem.Restore(s.RAM); err != nil {
+		return err
+	}
+	if err := g.vram.restore(s.VRAM); err != nil {
+		return err
+	}
+	if g.wram != nil {
+		if err := g.wram.restore(s.WRAM); err != nil {
+			return err
+		}
+	}
+	if g.hdma != nil {
+		g.hdma.restore(s.HDMA)
+	}
+	if g.key1 != nil {
+		g.key1.restore(s.KEY1)
+	}
+	if g.joypad.sgb != nil {
+		g.joypad.sgb.restore(s.SGB)
+	}
+	if g.bgPalettes != nil {
+		if err := g.bgPalettes.restore(s.BGPalettes); err != nil {
+			return err
+		}
+		if err := g.objPalettes.restore(s.OBJPalettes); err != nil {
+			return err
+		}
+	}
+	if g.cart != nil {
+		if err := g.cart.restore(s.Cart); err != nil {
+			return err
+		}
+	}
+
+	for _, rt := range gbStateRegisters {
+		g.cpu.pokeRegister(s.Registers[rt], rt)
+	}
+	g.cpu.restore(s.CPU)
+	g.ppu.restore(s.PPU)
+	g.apu.restore(s.APU)
+	g.joypad.selectBits = s.Joypad
+	g.serial.sb, g.serial.sc = s.Serial[0], s.Serial[1]
+	g.timer.restore(s.Timer)
+	g.dma.restore(s.DMA)
+	g.wait = s.Wait
+	g.cycles = s.Cycles
+	g.frames = s.Frames
+	g.updateClock()
+
+	return nil
+}
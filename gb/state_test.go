@@ -0,0 +1,94 @@
+package gb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSaveLoadState tests that loading a saved state reproduces the same
+// execution as an uninterrupted run.
+func TestSaveLoadState(t *testing.T) {
+	g := prepareGameboy(t, testProgram)
+	runFrames(t, g, 10)
+	saved, err := g.SaveState()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	runFrames(t, g, 5)
+	assert.NoError(t, g.LoadState(saved))
+	runFrames(t, g, 5)
+
+	expected := prepareGameboy(t, testProgram)
+	runFrames(t, expected, 15)
+
+	expectedState, err := expected.SaveState()
+	assert.NoError(t, err)
+	actualState, err := g.SaveState()
+	assert.NoError(t, err)
+	assert.Equal(t, expectedState, actualState)
+	assert.Equal(t, expected.cpu.readRegister(gbRegisterPC),
+		g.cpu.readRegister(gbRegisterPC))
+}
+
+// TestLoadStateModelMismatch tests that states can't be loaded into a gameboy
+// of a different model.
+func TestLoadStateModelMismatch(t *testing.T) {
+	saved, err := NewGameboy(WithModel(DMG)).SaveState()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	g := NewGameboy(WithModel(CGB))
+	assert.Equal(t, gbErrStateModelMismatch, g.LoadState(saved))
+}
+
+// TestSaveLoadStateJoypad tests that the joypad's selected row survives a
+// save state round trip.
+func TestSaveLoadStateJoypad(t *testing.T) {
+	g := NewGameboy()
+	assert.NoError(t, g.bus.poke(gbAddrP1, 0x10)) // select the buttons
+	saved, err := g.SaveState()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.NoError(t, g.bus.poke(gbAddrP1, 0x20)) // select the directions
+	assert.NoError(t, g.LoadState(saved))
+	assert.Equal(t, uint8(0x10), g.joypad.selectBits)
+}
+
+// TestSaveLoadStateAPU tests that the APU's channels, sweep and frame
+// sequencer survive a save state round trip, and that Reset clears them.
+func TestSaveLoadStateAPU(t *testing.T) {
+	g := NewGameboy()
+	assert.NoError(t, g.bus.poke(gbAddrNR10, 0x1<<gbNR10PeriodShift|gbNR10Negate|1))
+	assert.NoError(t, g.bus.poke(gbAddrNR11, 0x3F)) // a length of 1
+	assert.NoError(t, g.bus.poke(gbAddrNR14, gbNRx4Trigger|gbNRx4LengthEnable|0x4))
+	for i := 0; i < 100; i++ {
+		assert.NoError(t, g.Step())
+	}
+	saved, err := g.SaveState()
+	if !assert.NoError(t, err) {
+		return
+	}
+	expected := g.apu.snapshot()
+
+	// The length runs out and the sweep moves on after the save.
+	for i := 0; i < 4*gbFrameSequencerCycles; i++ {
+		assert.NoError(t, g.Step())
+	}
+	nr52, err := g.bus.read(gbAddrNR52)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0), nr52&0x1)
+
+	assert.NoError(t, g.LoadState(saved))
+	assert.Equal(t, expected, g.apu.snapshot())
+	nr52, err = g.bus.read(gbAddrNR52)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0x1), nr52&0x1)
+
+	g.Reset()
+	assert.Equal(t, gbAPUState{}, g.apu.snapshot())
+}
@@ -0,0 +1,74 @@
+package gb
+
+// MemoryStats counts the accesses made through a memory bus.
+type MemoryStats struct {
+	Reads, Writes uint64
+
+	// Per-region counts. Accesses outside these regions, such as to cartridge
+	// RAM, are only included in the totals.
+	ROM, VRAM, WRAM, OAM, IO, HRAM RegionStats
+}
+
+// RegionStats counts the accesses made to one region of memory.
+type RegionStats struct {
+	Reads, Writes uint64
+}
+
+// region returns the counts for the region containing the given address, or
+// nil if it isn't in one of the tracked regions.
+func (s *MemoryStats) region(addr uint32) *RegionStats {
+	switch {
+	case addr <= gbAddrROMEnd:
+		return &s.ROM
+	case addr <= gbAddrVRAMEnd:
+		return &s.VRAM
+	case addr <= gbAddrExtRAMEnd:
+		return nil // cartridge RAM
+	case addr <= gbAddrEchoRAMEnd:
+		return &s.WRAM // including echo RAM
+	case addr <= gbAddrOAMEnd:
+		return &s.OAM
+	case addr <= gbAddrUnusableEnd:
+		return nil
+	case addr <= gbAddrIOEnd:
+		return &s.IO
+	case addr <= gbAddrHRAMEnd:
+		return &s.HRAM
+	}
+
+	return &s.IO // interrupt enable
+}
+
+func (s *MemoryStats) recordRead(addr uint32) {
+	s.Reads++
+	if r := s.region(addr); r != nil {
+		r.Reads++
+	}
+}
+
+func (s *MemoryStats) recordWrite(addr uint32) {
+	s.Writes++
+	if r := s.region(addr); r != nil {
+		r.Writes++
+	}
+}
+
+// EnableStats turns counting of memory accesses on or off. Turning it on
+// resets the counts.
+func (b *gbMemoryBus) EnableStats(enabled bool) {
+	b.stats = nil
+	if enabled {
+		b.stats = &MemoryStats{}
+	}
+}
+
+// Stats returns the memory accesses counted since stats were enabled. Each
+// byte of a multi-byte read counts as a read, so fetching an instruction
+// counts one read for each of its bytes.
+func (b *gbMemoryBus) Stats() MemoryStats {
+	if b.stats == nil {
+		return MemoryStats{}
+	}
+
+	return *b.stats
+}
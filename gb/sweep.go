@@ -0,0 +1,102 @@
+package gb
+
+const (
+	gbAddrNR13 uint32 = 0xFF13 // channel 1 frequency, low byte
+
+	gbNR10PeriodMask  uint8 = 0x70 // 0b01110000
+	gbNR10PeriodShift       = 4
+	gbNR10Negate      uint8 = 0x1 << 3 // the sweep lowers the frequency
+	gbNR10ShiftMask   uint8 = 0x7      // 0b00000111
+	gbNRx4FreqMask    uint8 = 0x7      // 0b00000111, high bits of frequency
+
+	gbFreqMax = 2047
+)
+
+// gbSweep is channel 1's frequency sweep. Every sweep period, at 128Hz, it
+// shifts the frequency right and adds the result to it, or subtracts it. If
+// the frequency would go above 2047, the channel is turned off instead.
+type gbSweep struct {
+	enabled bool
+	timer   int    // sweep clocks until the next sweep
+	shadow  uint16 // working copy of the channel's frequency
+}
+
+// sweepParams returns the period, direction and shift set in NR10.
+func (a *gbAPU) sweepParams() (period int, negate bool, shift uint) {
+	nr10 := a.regs[gbAddrNR10-gbAddrNR10]
+	return int(nr10&gbNR10PeriodMask) >> gbNR10PeriodShift, nr10&gbNR10Negate != 0,
+		uint(nr10 & gbNR10ShiftMask)
+}
+
+// ch1Freq returns channel 1's frequency, from NR13 and NR14.
+func (a *gbAPU) ch1Freq() uint16 {
+	return uint16(a.regs[gbAddrNR14-gbAddrNR10]&gbNRx4FreqMask)<<8 |
+		uint16(a.regs[gbAddrNR13-gbAddrNR10])
+}
+
+func (a *gbAPU) setCh1Freq(freq uint16) {
+	a.regs[gbAddrNR13-gbAddrNR10] = uint8(freq)
+	nr14 := &a.regs[gbAddrNR14-gbAddrNR10]
+	*nr14 = *nr14&^gbNRx4FreqMask | uint8(freq>>8)&gbNRx4FreqMask
+}
+
+// resetSweepTimer reloads the sweep timer. A period of 0 counts as 8.
+func (a *gbAPU) resetSweepTimer() {
+	period, _, _ := a.sweepParams()
+	if period == 0 {
+		period = 8
+	}
+	a.sweep.timer = period
+}
+
+// triggerSweep restarts the sweep when channel 1 is triggered.
+func (a *gbAPU) triggerSweep() {
+	period, _, shift := a.sweepParams()
+	a.sweep.shadow = a.ch1Freq()
+	a.sweep.enabled = period != 0 || shift != 0
+	a.resetSweepTimer()
+
+	if shift != 0 {
+		a.nextSweepFreq()
+	}
+}
+
+// nextSweepFreq returns the frequency the next sweep will set, turning
+// channel 1 off if it's out of range.
+func (a *gbAPU) nextSweepFreq() uint16 {
+	_, negate, shift := a.sweepParams()
+
+	delta := a.sweep.shadow >> shift
+	if negate {
+		return a.sweep.shadow - delta
+	}
+
+	freq := a.sweep.shadow + delta
+	if freq > gbFreqMax {
+		a.channels[0].enabled = false
+	}
+	return freq
+}
+
+func (a *gbAPU) clockSweep() {
+	a.sweep.timer--
+	if a.sweep.timer > 0 {
+		return
+	}
+	a.resetSweepTimer()
+
+	period, _, shift := a.sweepParams()
+	if !a.sweep.enabled || period == 0 {
+		return
+	}
+
+	freq := a.nextSweepFreq()
+	if freq > gbFreqMax || shift == 0 {
+		return
+	}
+	a.sweep.shadow = freq
+	a.setCh1Freq(freq)
+
+	// The frequency after this one is checked for overflow straight away.
+	a.nextSweepFreq()
+}
@@ -0,0 +1,137 @@
+package gb
+
+import "errors"
+
+const (
+	gbAddrDIV  uint32 = 0xFF04 // divider
+	gbAddrTIMA uint32 = 0xFF05 // timer counter
+	gbAddrTMA  uint32 = 0xFF06 // timer modulo
+	gbAddrTAC  uint32 = 0xFF07 // timer control
+
+	gbTACClockMask  uint8 = 0x3 // 0b00000011
+	gbTACEnable     uint8 = 0x1 << 2
+	gbTACUnusedBits uint8 = 0xF8 // 0b11111000
+
+	gbInterruptTimer uint8 = 0x1 << 2
+)
+
+var (
+	gbErrTimerAddress = errors.New("gbTimer: address isn't a timer register")
+)
+
+// gbTimerBits maps the clock select bits of TAC to the bit of the internal
+// counter that drives TIMA. TIMA is incremented whenever that bit falls, so
+// every 1024, 16, 64 or 256 cycles respectively.
+var gbTimerBits = [4]uint16{0x1 << 9, 0x1 << 3, 0x1 << 5, 0x1 << 7}
+
+// gbTimer backs the timer registers. DIV is the upper byte of a 16-bit
+// counter that's incremented every cycle, and TIMA counts the falling edges
+// of one of its bits, reloading from TMA and requesting the timer interrupt
+// when it overflows.
+// TODO(guy): The reload should happen a cycle after the overflow.
+type gbTimer struct {
+	mem ram // for requesting interrupts
+
+	counter        uint16
+	tima, tma, tac uint8
+}
+
+// gbTimerState is the serialisable internal state of a gbTimer.
+type gbTimerState struct {
+	Counter        uint16
+	TIMA, TMA, TAC uint8
+}
+
+func newGBTimer(mem ram) *gbTimer {
+	return &gbTimer{mem: mem}
+}
+
+func (t *gbTimer) snapshot() gbTimerState {
+	return gbTimerState{t.counter, t.tima, t.tma, t.tac}
+}
+
+func (t *gbTimer) restore(s gbTimerState) {
+	t.counter, t.tima, t.tma, t.tac = s.Counter, s.TIMA, s.TMA, s.TAC
+}
+
+// Tick moves the timer forward by the given number of cycles.
+func (t *gbTimer) Tick(tcycles int) error {
+	for i := 0; i < tcycles; i++ {
+		if err := t.update(func() { t.counter++ }); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// signal returns the input to TIMA, which is the selected counter bit while
+// the timer is enabled.
+func (t *gbTimer) signal() bool {
+	return t.tac&gbTACEnable != 0 && t.counter&gbTimerBits[t.tac&gbTACClockMask] != 0
+}
+
+// update applies the given change to the timer, incrementing TIMA if it
+// causes the signal to fall. This happens for writes to DIV and TAC as well
+// as the counter ticking over.
+func (t *gbTimer) update(change func()) error {
+	before := t.signal()
+	change()
+	if !before || t.signal() {
+		return nil
+	}
+
+	t.tima++
+	if t.tima != 0 {
+		return nil
+	}
+	t.tima = t.tma
+
+	flags, err := t.mem.read(gbAddrIF)
+	if err != nil {
+		return err
+	}
+	return t.mem.poke(gbAddrIF, flags|gbInterruptTimer)
+}
+
+func (t *gbTimer) poke(addr uint32, val uint8) error {
+	switch addr {
+	case gbAddrDIV:
+		return t.update(func() { t.counter = 0 })
+
+	case gbAddrTIMA:
+		t.tima = val
+		return nil
+
+	case gbAddrTMA:
+		t.tma = val
+		return nil
+
+	case gbAddrTAC:
+		return t.update(func() { t.tac = val &^ gbTACUnusedBits })
+	}
+
+	return gbErrTimerAddress
+}
+
+func (t *gbTimer) read(addr uint32) (uint8, error) {
+	switch addr {
+	case gbAddrDIV:
+		return uint8(t.counter >> 8), nil
+
+	case gbAddrTIMA:
+		return t.tima, nil
+
+	case gbAddrTMA:
+		return t.tma, nil
+
+	case gbAddrTAC:
+		return gbTACUnusedBits | t.tac, nil
+	}
+
+	return 0, gbErrTimerAddress
+}
+
+func (t *gbTimer) ReadSlice(addr, n uint32) ([]uint8, error) {
+	return readN(t, addr, n)
+}
@@ -0,0 +1,57 @@
+package gb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestTimerOverflow tests that TIMA overflows and requests the timer
+// interrupt after the right number of cycles for each clock select.
+func TestTimerOverflow(t *testing.T) {
+	testFn := func(clock uint8, period int) func(*testing.T) {
+		return func(t *testing.T) {
+			r := newGBRAM()
+			tm := newGBTimer(r)
+			assert.NoError(t, tm.poke(gbAddrTMA, 0x42))
+			assert.NoError(t, tm.poke(gbAddrTIMA, 0xFE))
+			assert.NoError(t, tm.poke(gbAddrTAC, gbTACEnable|clock))
+
+			cycles := 0
+			for r.mem[gbAddrIF]&gbInterruptTimer == 0 && cycles < 4*period {
+				assert.NoError(t, tm.Tick(1))
+				cycles++
+			}
+
+			assert.Equal(t, 2*period, cycles)
+			assert.Equal(t, uint8(0x42), tm.tima)
+		}
+	}
+
+	t.Run("4096Hz", testFn(0x0, 1024))
+	t.Run("262144Hz", testFn(0x1, 16))
+	t.Run("65536Hz", testFn(0x2, 64))
+	t.Run("16384Hz", testFn(0x3, 256))
+}
+
+// TestTimerDisabled tests that TIMA doesn't count while the timer is off,
+// while DIV always does.
+func TestTimerDisabled(t *testing.T) {
+	tm := newGBTimer(newGBRAM())
+	assert.NoError(t, tm.poke(gbAddrTAC, 0x1))
+	assert.NoError(t, tm.Tick(0x1234))
+
+	tima, err := tm.read(gbAddrTIMA)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0), tima)
+
+	div, err := tm.read(gbAddrDIV)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0x12), div)
+
+	// Any write to DIV resets it.
+	assert.NoError(t, tm.poke(gbAddrDIV, 0x42))
+	div, err = tm.read(gbAddrDIV)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0), div)
+}
@@ -0,0 +1,72 @@
+package gb
+
+// TraceEntry records the state of the CPU just before it executed an
+// instruction. Registers is indexed by gbRegisterType-1.
+type TraceEntry struct {
+	PC        uint16
+	Opcode    *gbOpcode
+	Registers [gbRegisterHL]uint16
+	Cycles    uint64 // quartz-cycles since the gameboy started
+}
+
+// TraceLogger keeps a ring buffer of the most recently executed
+// instructions. Once it's full, new entries overwrite the oldest ones.
+type TraceLogger struct {
+	entries []TraceEntry
+	head    int // index of the next entry to write
+	size    int // number of valid entries in the buffer
+}
+
+// NewTraceLogger returns a logger that keeps the last capacity instructions.
+// Capacities below 1 are raised to 1.
+func NewTraceLogger(capacity int) *TraceLogger {
+	if capacity < 1 {
+		capacity = 1
+	}
+
+	return &TraceLogger{
+		entries: make([]TraceEntry, capacity),
+	}
+}
+
+func (l *TraceLogger) record(c cpu, op *gbOpcode, cycles uint64) {
+	if len(l.entries) == 0 {
+		return
+	}
+
+	e := TraceEntry{
+		PC:     c.readRegister(gbRegisterPC),
+		Opcode: op,
+		Cycles: cycles,
+	}
+	for i := range e.Registers {
+		e.Registers[i] = c.readRegister(gbRegisterType(i + 1))
+	}
+
+	l.entries[l.head] = e
+	l.head = (l.head + 1) % len(l.entries)
+	if l.size < len(l.entries) {
+		l.size++
+	}
+}
+
+// Dump returns a copy of the buffered entries, oldest first.
+func (l *TraceLogger) Dump() []TraceEntry {
+	res := make([]TraceEntry, 0, l.size)
+	if l.size == 0 {
+		return res
+	}
+
+	start := (l.head - l.size + len(l.entries)) % len(l.entries)
+	for i := 0; i < l.size; i++ {
+		res = append(res, l.entries[(start+i)%len(l.entries)])
+	}
+
+	return res
+}
+
+// AttachTraceLogger makes the gameboy record every instruction it executes
+// into the given logger. Passing nil detaches the current logger.
+func (g *Gameboy) AttachTraceLogger(l *TraceLogger) {
+	g.tracer = l
+}
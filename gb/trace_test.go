@@ -0,0 +1,69 @@
+package gb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestTraceLogger tests that the trace logger keeps the most recent
+// instructions in execution order.
+func TestTraceLogger(t *testing.T) {
+	g := prepareGameboy(t, testProgram)
+	l := NewTraceLogger(50)
+	g.AttachTraceLogger(l)
+
+	runInstructions(t, g, 100)
+
+	entries := l.Dump()
+	if !assert.Len(t, entries, 50) {
+		return
+	}
+
+	for i := 1; i < len(entries); i++ {
+		prev, cur := entries[i-1], entries[i]
+		assert.Equal(t, prev.PC, prev.Registers[gbRegisterPC-1])
+		assert.Equal(t, prev.Cycles+uint64(4*prev.Opcode.cycles), cur.Cycles)
+
+		next := prev.PC + uint16(prev.Opcode.size())
+		if prev.Opcode.tipe == gbOpcodeJRN {
+			next = uint16(int32(next) + int32(int8(prev.Opcode.data[0])))
+		}
+		assert.Equal(t, next, cur.PC)
+	}
+}
+
+// TestTraceLoggerPartial tests dumping a logger that isn't full yet.
+func TestTraceLoggerPartial(t *testing.T) {
+	g := prepareGameboy(t, testProgram)
+	l := NewTraceLogger(50)
+	g.AttachTraceLogger(l)
+
+	runInstructions(t, g, 3)
+
+	entries := l.Dump()
+	if !assert.Len(t, entries, 3) {
+		return
+	}
+	assert.Equal(t, uint16(0x100), entries[0].PC)
+	assert.Equal(t, uint16(0x102), entries[1].PC)
+	assert.Equal(t, uint16(0x104), entries[2].PC)
+}
+
+// TestTraceLoggerCapacity tests that capacities below 1 keep the last
+// instruction.
+func TestTraceLoggerCapacity(t *testing.T) {
+	for _, capacity := range []int{0, -1} {
+		g := prepareGameboy(t, testProgram)
+		l := NewTraceLogger(capacity)
+		g.AttachTraceLogger(l)
+
+		runInstructions(t, g, 3)
+
+		entries := l.Dump()
+		if !assert.Len(t, entries, 1) {
+			return
+		}
+		assert.Equal(t, uint16(0x104), entries[0].PC)
+	}
+}
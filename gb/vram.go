@@ -0,0 +1,88 @@
+package gb
+
+import "errors"
+
+const (
+	gbAddrVBK      uint32 = 0xFF4F // video RAM bank select (CGB only)
+	gbVRAMBankSize        = 0x2000 // 8Kb
+
+	gbVBKBankMask   uint8 = 0x1  // 0b00000001
+	gbVBKUnusedBits uint8 = 0xFE // 0b11111110
+)
+
+var (
+	gbErrVRAMAddress = errors.New("vramController: address isn't video RAM or VBK")
+)
+
+// vramController backs video RAM. The CGB has two banks, the second of which
+// holds extra tile data and the background attribute maps, and the CPU sees
+// the bank selected by VBK. Other models only ever use bank 0. The PPU reads
+// both banks directly, regardless of which one the CPU sees.
+type vramController struct {
+	banks [2][gbVRAMBankSize]uint8
+	vbk   uint8
+}
+
+func newVRAMController() *vramController {
+	return &vramController{}
+}
+
+// bankByte returns the byte at the given VRAM address of the given bank.
+func (v *vramController) bankByte(bank int, addr uint32) uint8 {
+	return v.banks[bank][addr-gbAddrVRAM]
+}
+
+func (v *vramController) poke(addr uint32, val uint8) error {
+	switch {
+	case addr == gbAddrVBK:
+		v.vbk = val & gbVBKBankMask
+
+	case addr >= gbAddrVRAM && addr <= gbAddrVRAMEnd:
+		v.banks[v.vbk][addr-gbAddrVRAM] = val
+
+	default:
+		return gbErrVRAMAddress
+	}
+
+	return nil
+}
+
+func (v *vramController) read(addr uint32) (uint8, error) {
+	switch {
+	case addr == gbAddrVBK:
+		return gbVBKUnusedBits | v.vbk, nil
+
+	case addr >= gbAddrVRAM && addr <= gbAddrVRAMEnd:
+		return v.banks[v.vbk][addr-gbAddrVRAM], nil
+	}
+
+	return 0, gbErrVRAMAddress
+}
+
+func (v *vramController) ReadSlice(addr, n uint32) ([]uint8, error) {
+	return readN(v, addr, n)
+}
+
+// snapshot returns a copy of both banks, followed by the VBK register.
+func (v *vramController) snapshot() []uint8 {
+	snap := make([]uint8, 0, len(v.banks)*gbVRAMBankSize+1)
+	for i := range v.banks {
+		snap = append(snap, v.banks[i][:]...)
+	}
+
+	return append(snap, v.vbk)
+}
+
+// restore loads a snapshot taken by snapshot.
+func (v *vramController) restore(snap []uint8) error {
+	if len(snap) != len(v.banks)*gbVRAMBankSize+1 {
+		return gbErrSnapshotSize
+	}
+
+	for i := range v.banks {
+		copy(v.banks[i][:], snap[i*gbVRAMBankSize:])
+	}
+	v.vbk = snap[len(snap)-1]
+
+	return nil
+}
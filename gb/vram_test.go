@@ -0,0 +1,218 @@
+package gb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// prepareBackground sets up a gameboy whose background shows tile 1 in the
+// top left corner of the screen and tile 0 everywhere else, using unsigned
+// tile indices and the 0x9800 tile map.
+func prepareBackground(t *testing.T, model GameboyModel) *Gameboy {
+	g := NewGameboy(WithModel(model))
+	assert.NoError(t, pokeN(g.bus, 0x100, testProgram))
+	assert.NoError(t, g.bus.poke(gbAddrLCDC, 0x91))
+	assert.NoError(t, g.bus.poke(gbAddrTileMap0, 0x01))
+
+	return g
+}
+
+// fillTile sets every pixel of the given tile's data to the given colour.
+func fillTile(t *testing.T, g *Gameboy, addr uint32, color uint8) {
+	lo, hi := uint8(0x00), uint8(0x00)
+	if color&0x1 != 0 {
+		lo = 0xFF
+	}
+	if color&0x2 != 0 {
+		hi = 0xFF
+	}
+
+	for row := uint32(0); row < 8; row++ {
+		assert.NoError(t, g.bus.poke(addr+row*2, lo))
+		assert.NoError(t, g.bus.poke(addr+row*2+1, hi))
+	}
+}
+
+// TestVRAMBanking tests switching between CGB video RAM banks.
+func TestVRAMBanking(t *testing.T) {
+	g := NewGameboy(WithModel(CGB))
+
+	assert.NoError(t, g.bus.poke(0x8123, 0x11))
+	assert.NoError(t, g.bus.poke(gbAddrVBK, 0x01))
+	assert.NoError(t, g.bus.poke(0x8123, 0x22))
+
+	val, err := g.bus.read(0x8123)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0x22), val)
+
+	vbk, err := g.bus.read(gbAddrVBK)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0xFF), vbk)
+
+	assert.NoError(t, g.bus.poke(gbAddrVBK, 0x00))
+	val, err = g.bus.read(0x8123)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0x11), val)
+}
+
+// TestSetVRAMBank tests that the PPU's bank switch only changes what the CPU
+// sees, and that the PPU keeps drawing from both banks.
+func TestSetVRAMBank(t *testing.T) {
+	g := prepareBackground(t, CGB)
+	fillTile(t, g, 0x8010, 3)
+
+	// The top left tile's attributes use palette 5, where colour 3 is red.
+	writePaletteColor(t, g, gbAddrBCPS, 5, 3, 0x001F)
+	assert.NoError(t, g.bus.poke(gbAddrVBK, 0x01))
+	assert.NoError(t, g.bus.poke(gbAddrTileMap0, 0x05))
+	assert.NoError(t, g.bus.poke(0x8010, 0x42))
+
+	// Bank 1 has its own copy of the tile's first byte.
+	g.ppu.SetVRAMBank(0)
+	val, err := g.bus.read(0x8010)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0xFF), val)
+	vbk, err := g.bus.read(gbAddrVBK)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0xFE), vbk)
+
+	g.ppu.SetVRAMBank(1)
+	val, err = g.bus.read(0x8010)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0x42), val)
+
+	runFrames(t, g, 1)
+	assert.Equal(t, uint16(0x001F), g.GetColorFramebuffer()[0])
+}
+
+// TestVRAMBankingDMG tests that non-CGB models ignore VBK.
+func TestVRAMBankingDMG(t *testing.T) {
+	g := NewGameboy(WithModel(DMG))
+
+	assert.NoError(t, g.bus.poke(0x8123, 0x11))
+	assert.NoError(t, g.bus.poke(gbAddrVBK, 0x01))
+	g.ppu.SetVRAMBank(1)
+	val, err := g.bus.read(0x8123)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0x11), val)
+}
+
+// TestRenderBackground tests drawing a background tile on the DMG.
+func TestRenderBackground(t *testing.T) {
+	g := prepareBackground(t, DMG)
+	fillTile(t, g, 0x8010, 1)
+	runFrames(t, g, 1)
+
+	fb := g.GetFramebuffer()
+	assert.Len(t, fb, gbScreenWidth*gbScreenHeight)
+	assert.Equal(t, uint8(1), fb[0])
+	assert.Equal(t, uint8(1), fb[7*gbScreenWidth+7])
+	assert.Equal(t, uint8(0), fb[8])
+	assert.Equal(t, uint8(0), fb[8*gbScreenWidth])
+}
+
+// TestRenderBackgroundSignedTiles tests that with LCDC bit 4 clear, tile
+// indices are signed offsets from 0x9000.
+func TestRenderBackgroundSignedTiles(t *testing.T) {
+	g := prepareBackground(t, DMG)
+	assert.NoError(t, g.bus.poke(gbAddrLCDC, 0x81))
+	assert.NoError(t, g.bus.poke(gbAddrTileMap0, 0x00))
+	assert.NoError(t, g.bus.poke(gbAddrTileMap0+1, 0x80))
+	assert.NoError(t, g.bus.poke(gbAddrTileMap0+2, 0x01))
+
+	// Tile 0 in the unsigned area is left blank to catch a wrong base.
+	fillTile(t, g, gbAddrTileData1, 3)
+	fillTile(t, g, 0x8800, 2)
+	fillTile(t, g, 0x8010, 1)
+	runFrames(t, g, 1)
+
+	fb := g.GetFramebuffer()
+	assert.Equal(t, uint8(3), fb[0])
+	assert.Equal(t, uint8(3), fb[7*gbScreenWidth+7])
+	assert.Equal(t, uint8(2), fb[8])
+	assert.Equal(t, uint8(0), fb[16])
+}
+
+// TestRenderBackgroundBanks tests that CGB background tiles take their
+// attributes from VRAM bank 1.
+func TestRenderBackgroundBanks(t *testing.T) {
+	g := prepareBackground(t, CGB)
+	fillTile(t, g, 0x8010, 1)
+
+	// Bank 1 has a different tile 1, and the attributes select it.
+	assert.NoError(t, g.bus.poke(gbAddrVBK, 0x01))
+	fillTile(t, g, 0x8010, 2)
+	assert.NoError(t, g.bus.poke(gbAddrTileMap0, gbBGAttrBank|gbBGAttrXFlip))
+
+	// Only the leftmost pixel of the first row is set, which is flipped.
+	assert.NoError(t, g.bus.poke(0x8011, 0x80))
+	assert.NoError(t, g.bus.poke(gbAddrVBK, 0x00))
+	runFrames(t, g, 1)
+
+	fb := g.GetFramebuffer()
+	assert.Equal(t, uint8(0), fb[0])
+	assert.Equal(t, uint8(2), fb[7])
+	assert.Equal(t, uint8(2), fb[gbScreenWidth])
+	assert.Equal(t, uint8(0), fb[8])
+}
+
+// TestVRAMBlockedDuringTransfer tests that the CPU can't see video RAM while
+// the PPU is transferring pixels.
+func TestVRAMBlockedDuringTransfer(t *testing.T) {
+	g := NewGameboy()
+	assert.NoError(t, g.bus.poke(0x8123, 0x42))
+
+	for i := 0; i < gbOAMScanDots; i++ {
+		assert.NoError(t, g.ppu.Tick(1))
+	}
+	assert.Equal(t, gbPPUModeTransfer, g.ppu.Mode())
+	assert.False(t, g.ppu.IsVRAMAccessible())
+
+	val, err := g.bus.read(0x8123)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0xFF), val)
+	assert.NoError(t, g.bus.poke(0x8123, 0x24))
+
+	for i := 0; i < gbTransferDots; i++ {
+		assert.NoError(t, g.ppu.Tick(1))
+	}
+	assert.Equal(t, gbPPUModeHBlank, g.ppu.Mode())
+	assert.True(t, g.ppu.IsVRAMAccessible())
+
+	val, err = g.bus.read(0x8123)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0x42), val)
+}
+
+// TestOAMBlockedDuringScanAndTransfer tests that the CPU can't see OAM while
+// the PPU is scanning it or transferring pixels.
+func TestOAMBlockedDuringScanAndTransfer(t *testing.T) {
+	g := NewGameboy()
+	assert.NoError(t, g.bus.mem.poke(gbAddrOAM, 0x42))
+
+	// The PPU starts out scanning OAM, so writes are discarded.
+	assert.Equal(t, gbPPUModeOAMScan, g.ppu.Mode())
+	assert.False(t, g.ppu.IsOAMAccessible())
+	val, err := g.bus.read(gbAddrOAM)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0xFF), val)
+	assert.NoError(t, g.bus.poke(gbAddrOAM, 0x24))
+
+	for i := 0; i < gbOAMScanDots+gbTransferDots; i++ {
+		assert.NoError(t, g.ppu.Tick(1))
+	}
+	assert.Equal(t, gbPPUModeHBlank, g.ppu.Mode())
+	val, err = g.bus.read(gbAddrOAM)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0x42), val)
+
+	// Skip ahead to the V-blank period.
+	for g.ppu.Mode() != gbPPUModeVBlank {
+		assert.NoError(t, g.ppu.Tick(1))
+	}
+	assert.True(t, g.ppu.IsOAMAccessible())
+	val, err = g.bus.read(gbAddrOAM)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0x42), val)
+}
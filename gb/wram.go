@@ -0,0 +1,104 @@
+package gb
+
+import "errors"
+
+const (
+	gbAddrSVBK       uint32 = 0xFF70 // work RAM bank select (CGB only)
+	gbWRAMBankSize          = 0x1000 // 4Kb
+	gbSVBKBankMask   uint8  = 0x7    // 0b00000111
+	gbSVBKUnusedBits uint8  = 0xF8   // 0b11111000
+)
+
+var (
+	gbErrWRAMAddress = errors.New("wramController: address isn't work RAM or SVBK")
+)
+
+// wramController backs the CGB's banked work RAM. Bank 0 is always mapped to
+// 0xC000-0xCFFF, and the bank selected by SVBK is mapped to 0xD000-0xDFFF.
+type wramController struct {
+	banks [8][gbWRAMBankSize]uint8
+	svbk  uint8
+}
+
+func newWRAMController() *wramController {
+	return &wramController{}
+}
+
+// bank returns the bank selected by SVBK, where 0 selects bank 1.
+func (w *wramController) bank() int {
+	if b := w.svbk & gbSVBKBankMask; b != 0 {
+		return int(b)
+	}
+
+	return 1
+}
+
+// cell returns the byte backing the given work RAM address, or nil if it
+// isn't a work RAM address.
+func (w *wramController) cell(addr uint32) *uint8 {
+	switch {
+	case addr >= gbAddrWRAM0 && addr < gbAddrWRAMX:
+		return &w.banks[0][addr-gbAddrWRAM0]
+
+	case addr >= gbAddrWRAMX && addr <= gbAddrWRAMXEnd:
+		return &w.banks[w.bank()][addr-gbAddrWRAMX]
+	}
+
+	return nil
+}
+
+func (w *wramController) poke(addr uint32, val uint8) error {
+	if addr == gbAddrSVBK {
+		w.svbk = val & gbSVBKBankMask
+		return nil
+	}
+
+	c := w.cell(addr)
+	if c == nil {
+		return gbErrWRAMAddress
+	}
+
+	*c = val
+	return nil
+}
+
+func (w *wramController) read(addr uint32) (uint8, error) {
+	if addr == gbAddrSVBK {
+		return gbSVBKUnusedBits | w.svbk, nil
+	}
+
+	c := w.cell(addr)
+	if c == nil {
+		return 0, gbErrWRAMAddress
+	}
+
+	return *c, nil
+}
+
+func (w *wramController) ReadSlice(addr, n uint32) ([]uint8, error) {
+	return readN(w, addr, n)
+}
+
+// snapshot returns a copy of every bank, followed by the SVBK register.
+func (w *wramController) snapshot() []uint8 {
+	snap := make([]uint8, 0, len(w.banks)*gbWRAMBankSize+1)
+	for i := range w.banks {
+		snap = append(snap, w.banks[i][:]...)
+	}
+
+	return append(snap, w.svbk)
+}
+
+// restore loads a snapshot taken by snapshot.
+func (w *wramController) restore(snap []uint8) error {
+	if len(snap) != len(w.banks)*gbWRAMBankSize+1 {
+		return gbErrSnapshotSize
+	}
+
+	for i := range w.banks {
+		copy(w.banks[i][:], snap[i*gbWRAMBankSize:])
+	}
+	w.svbk = snap[len(snap)-1]
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package gb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestWRAMBanking tests switching between CGB work RAM banks.
+func TestWRAMBanking(t *testing.T) {
+	g := NewGameboy(WithModel(CGB))
+
+	assert.NoError(t, g.bus.poke(gbAddrSVBK, 0x01))
+	assert.NoError(t, g.bus.poke(0xD123, 0x11))
+	assert.NoError(t, g.bus.poke(gbAddrSVBK, 0x02))
+	assert.NoError(t, g.bus.poke(0xD123, 0x22))
+
+	val, err := g.bus.read(0xD123)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0x22), val)
+
+	// Selecting bank 0 selects bank 1 instead.
+	assert.NoError(t, g.bus.poke(gbAddrSVBK, 0x00))
+	val, err = g.bus.read(0xD123)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0x11), val)
+
+	svbk, err := g.bus.read(gbAddrSVBK)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0xF8), svbk)
+
+	// Bank 0 is fixed regardless of the selected bank.
+	assert.NoError(t, g.bus.poke(0xC123, 0x33))
+	assert.NoError(t, g.bus.poke(gbAddrSVBK, 0x07))
+	val, err = g.bus.read(0xC123)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0x33), val)
+}
+
+// TestWRAMBankingDMG tests that non-CGB models don't bank work RAM.
+func TestWRAMBankingDMG(t *testing.T) {
+	g := NewGameboy(WithModel(DMG))
+	assert.Nil(t, g.wram)
+
+	assert.NoError(t, g.bus.poke(0xD123, 0x11))
+	assert.NoError(t, g.bus.poke(gbAddrSVBK, 0x02))
+	val, err := g.bus.read(0xD123)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0x11), val)
+}
+
+// TestWRAMSaveState tests that banked work RAM survives a save state round
+// trip.
+func TestWRAMSaveState(t *testing.T) {
+	g := NewGameboy(WithModel(CGB))
+	assert.NoError(t, g.bus.poke(gbAddrSVBK, 0x03))
+	assert.NoError(t, g.bus.poke(0xD000, 0x42))
+	saved, err := g.SaveState()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.NoError(t, g.bus.poke(0xD000, 0x00))
+	assert.NoError(t, g.bus.poke(gbAddrSVBK, 0x01))
+	assert.NoError(t, g.LoadState(saved))
+
+	val, err := g.bus.read(0xD000)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0x42), val)
+}